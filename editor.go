@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+
+	"mend/internal/editor"
+	"mend/internal/ui/note"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// the external editor is done with a note
+type editorDoneMsg struct {
+	path string
+	err  error
+}
+
+// hands the terminal to the external editor, starting at the section on
+// screen when it's the open note
+func (m *model) openInEditor(path string) tea.Cmd {
+	args, err := editor.Resolve(m.config.Editor)
+	if err != nil {
+		m.tree.ErrMsg = err.Error()
+		return nil
+	}
+	line := 1
+	if path == m.noteView.Path {
+		line = m.noteView.SectionLine()
+	}
+	c := editor.Command(args, path, line)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return editorDoneMsg{path: path, err: err}
+	})
+}
+
+func (m *model) editorDone(msg editorDoneMsg) tea.Cmd {
+	if msg.err != nil {
+		m.tree.ErrMsg = "editor: " + msg.err.Error()
+	}
+	section := 0
+	if msg.path == m.noteView.Path {
+		section = m.noteView.CurrentSection()
+	}
+	// mouse loses focus so this is neededd
+	reload := func() tea.Msg { return note.LoadNoteMsg{Path: msg.path, Section: section, Force: true} }
+	return tea.Batch(reload, m.reindex()) // the watcher doesn't see writes
+}
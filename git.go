@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"mend/internal/git"
+	"mend/internal/ui/fstree"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fresh git status of every root that is a repo
+type gitStatusMsg struct {
+	statuses map[string]git.Status
+	err      error
+}
+
+// repos for the roots that are in git, roots that aren't are left out
+func openRepos(roots []string) []*git.Repo {
+	repos := make([]*git.Repo, 0)
+	for _, root := range roots {
+		if repo, err := git.Open(root); err == nil {
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
+
+func (m *model) refreshGit() tea.Cmd {
+	if len(m.repos) == 0 {
+		return nil
+	}
+	repos := m.repos
+	return func() tea.Msg {
+		return gitStatusMsg{statuses: collectStatus(repos)}
+	}
+}
+
+// commits the paths a change touched when auto commit is on, then refreshes
+// the markers either way. nothing happens for a change that touched nothing
+func (m *model) afterChange(message string, paths ...string) tea.Cmd {
+	if len(m.repos) == 0 || len(paths) == 0 {
+		return nil
+	}
+	if !m.config.GitAutoCommit {
+		return m.refreshGit()
+	}
+	repos := m.repos
+	return func() tea.Msg {
+		var commitErr error
+		for _, repo := range repos {
+			if _, err := repo.Commit(message, paths); err != nil && commitErr == nil {
+				commitErr = fmt.Errorf("git commit in %s: %w", filepath.Base(repo.Root()), err)
+			}
+		}
+		return gitStatusMsg{statuses: collectStatus(repos), err: commitErr}
+	}
+}
+
+func collectStatus(repos []*git.Repo) map[string]git.Status {
+	all := make(map[string]git.Status)
+	for _, repo := range repos {
+		statuses, err := repo.Status()
+		if err != nil {
+			continue // markers for this root just stay off
+		}
+		for path, status := range statuses {
+			all[path] = status
+		}
+	}
+	return all
+}
+
+func actionCommitMessage(msg fstree.PerformActionMsg) string {
+	switch msg.Action {
+	case fstree.ActionNewFile:
+		return "Add " + msg.Name
+	case fstree.ActionNewFolder, fstree.ActionNewRoot:
+		return "Add folder " + msg.Name
+	case fstree.ActionMove:
+		return "Move notes to " + msg.Name
+	case fstree.ActionTag:
+		return "Tag notes with " + msg.Name
+	case fstree.ActionRename:
+		return "Rename to " + msg.Name
+	}
+	return "Update notes"
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+
+	"mend/internal/filesystem"
+	"mend/internal/history"
+	"mend/internal/ui/note"
+	"mend/internal/ui/palette"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const recentPaletteID = "recent"
+
+// where the note view is right now, as the history sees it. an attachment on
+// screen counts as nothing open so it never lands in back or recents
+func (m *model) currentEntry() history.Entry {
+	if filesystem.KindOf(m.noteView.Path) != filesystem.KindNote {
+		return history.Entry{}
+	}
+	return history.Entry{Path: m.noteView.Path, Section: m.noteView.CurrentSection()}
+}
+
+// openNote loads a note and records it in the history, anything that isn't a
+// note (folders, attachments) is shown but not remembered
+func (m *model) openNote(path string, section int) tea.Cmd {
+	if filesystem.KindOf(path) == filesystem.KindNote {
+		m.history.Navigate(m.currentEntry(), history.Entry{Path: path, Section: section})
+	}
+	return func() tea.Msg { return note.LoadNoteMsg{Path: path, Section: section} }
+}
+
+// goes to an entry taken from back/forward without recording it again
+func (m *model) jumpTo(entry history.Entry) tea.Cmd {
+	if m.tree != nil {
+		m.tree.RestoreSelection(entry.Path)
+	}
+	return func() tea.Msg { return note.LoadNoteMsg{Path: entry.Path, Section: entry.Section} }
+}
+
+func (m *model) goBack() tea.Cmd {
+	entry, ok := m.history.Back(m.currentEntry())
+	if !ok {
+		return nil
+	}
+	return m.jumpTo(entry)
+}
+
+func (m *model) goForward() tea.Cmd {
+	entry, ok := m.history.Forward(m.currentEntry())
+	if !ok {
+		return nil
+	}
+	return m.jumpTo(entry)
+}
+
+func (m *model) openRecentPalette() tea.Cmd {
+	items := make([]palette.Item, 0)
+	for _, entry := range m.history.Recent() {
+		if entry.Path == m.noteView.Path {
+			continue // already looking at it
+		}
+		if _, err := os.Stat(entry.Path); err != nil {
+			continue
+		}
+		items = append(items, palette.Item{
+			Title: m.tree.RelPath(entry.Path),
+			Value: entry.Path,
+		})
+	}
+	_, sizeCmd := m.palette.Update(tea.WindowSizeMsg{Width: m.terminalWidth, Height: m.terminalHeight})
+	return tea.Batch(sizeCmd, m.palette.Open(recentPaletteID, "Recent notes", items))
+}
+
+// picking a recent note goes back to the section it was left on
+func (m *model) openRecent(path string) tea.Cmd {
+	if m.tree != nil {
+		m.tree.RestoreSelection(path)
+	}
+	return m.openNote(path, m.history.Section(path))
+}
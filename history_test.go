@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mend/internal/history"
+	"mend/internal/ui/note"
+)
+
+// tests that leaving an attachment doesn't put it in back or recents
+func TestHistorySkipsAttachments(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_main_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	image := filepath.Join(tmpDir, "photo.png")
+	a := filepath.Join(tmpDir, "a.md")
+	os.WriteFile(image, []byte{}, 0644)
+	os.WriteFile(a, []byte("# A\n"), 0644)
+
+	m := &model{history: history.New(), noteView: note.NewNoteView()}
+	m.noteView.Path = image // shown, not remembered
+	m.openNote(a, 0)
+
+	if back := m.history.BackList(); len(back) != 0 {
+		t.Errorf("expected nothing to go back to, got %v", back)
+	}
+	if recent := m.history.Recent(); len(recent) != 1 || recent[0].Path != a {
+		t.Errorf("expected only the note in recents, got %v", recent)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+user level settings, shared by every root. lives in the os config folder
+(~/.config/mend/config.json on linux) and is only ever read, a missing file
+just means defaults.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Config struct {
+	ShowOnlyNotes  bool     `json:"show_only_notes"` // hide images, pdfs and other files in the tree
+	HonorGitignore bool     `json:"honor_gitignore"` // .mendignore always applies, .gitignore only with this
+	Roots          []string `json:"roots"`           // opened when no root is given on the command line, ~ allowed
+	GitAutoCommit  bool     `json:"git_auto_commit"` // commit roots that are git repos after every save or tree change
+	Versions       int      `json:"versions"`        // old versions kept per note in .mend/versions, 0 keeps none
+	// autosave while editing, to a draft in .mend/recovery unless AutosaveToNote is set
+	AutosaveIdle     int  `json:"autosave_idle"`     // seconds without typing before saving, 0 turns autosave off
+	AutosaveInterval int  `json:"autosave_interval"` // seconds between saves while typing without a pause
+	AutosaveToNote   bool `json:"autosave_to_note"`  // write the note itself instead of a draft
+	// external editor for o, {file} and {line} are filled in. $VISUAL and $EDITOR come next
+	Editor string `json:"editor"`
+}
+
+func Default() *Config {
+	return &Config{
+		HonorGitignore:   true,
+		Versions:         20,
+		AutosaveIdle:     2,
+		AutosaveInterval: 30,
+	}
+}
+
+// Path is where the config file is expected, empty if there is no config folder
+func Path() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mend", "config.json")
+}
+
+func Load() (*Config, error) {
+	path := Path()
+	if path == "" {
+		return Default(), nil
+	}
+	return LoadFile(path)
+}
+
+func LoadFile(path string) (*Config, error) {
+	cfg := Default()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return Default(), err
+	}
+	return cfg, nil
+}
+
+// RootPaths are the configured roots with ~ expanded
+func (c *Config) RootPaths() []string {
+	home, _ := os.UserHomeDir()
+	paths := make([]string, 0, len(c.Roots))
+	for _, root := range c.Roots {
+		if home != "" && (root == "~" || strings.HasPrefix(root, "~/")) {
+			root = filepath.Join(home, strings.TrimPrefix(root, "~"))
+		}
+		paths = append(paths, root)
+	}
+	return paths
+}
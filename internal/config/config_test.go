@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tests defaults and overrides from a file
+func TestLoadFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_config_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "config.json")
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("expected missing file to give defaults, got %v", err)
+	}
+	if cfg.ShowOnlyNotes {
+		t.Error("expected default to show all files")
+	}
+
+	os.WriteFile(path, []byte(`{"show_only_notes": true}`), 0644)
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.ShowOnlyNotes {
+		t.Error("expected value from file")
+	}
+
+	os.WriteFile(path, []byte(`{not json`), 0644)
+	if _, err := LoadFile(path); err == nil {
+		t.Error("expected error for broken file")
+	}
+}
+
+// tests expanding ~ in configured roots
+func TestRootPaths(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home folder")
+	}
+	cfg := &Config{Roots: []string{"~/notes", "/srv/shared"}}
+	paths := cfg.RootPaths()
+	if len(paths) != 2 || paths[0] != filepath.Join(home, "notes") || paths[1] != "/srv/shared" {
+		t.Errorf("RootPaths() = %v", paths)
+	}
+}
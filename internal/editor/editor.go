@@ -0,0 +1,125 @@
+/*
+the external editor notes are opened in with o. the command comes from the
+config, then $VISUAL, then $EDITOR, then the first common editor found on the
+path. it can carry arguments: {file} is replaced by the note and {line} by the
+line to start on, without {file} the note goes last.
+*/
+
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// tried in order when nothing is configured
+var fallbacks = []string{"nvim", "vim", "vi", "nano", "micro"}
+
+// editors that take +line before the file, used when the command says nothing about the line
+var plusLine = map[string]bool{"vi": true, "vim": true, "nvim": true, "nano": true, "micro": true, "emacs": true, "kak": true}
+
+// Resolve gives the editor command split into arguments. a configured or
+// environment editor that isn't installed is skipped, if nothing is left the
+// error says where the first one was missing
+func Resolve(configured string) ([]string, error) {
+	var firstErr error
+	for _, source := range []struct{ name, command string }{
+		{"editor in the config", configured},
+		{"$VISUAL", os.Getenv("VISUAL")},
+		{"$EDITOR", os.Getenv("EDITOR")},
+	} {
+		if strings.TrimSpace(source.command) == "" {
+			continue
+		}
+		args, err := Split(source.command)
+		if err == nil {
+			_, err = exec.LookPath(args[0])
+		}
+		if err == nil {
+			return args, nil
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("%s %q can't be used: %w", source.name, source.command, err)
+		}
+	}
+
+	candidates := fallbacks
+	if runtime.GOOS == "windows" {
+		candidates = append(candidates, "notepad")
+	}
+	for _, name := range candidates {
+		if _, err := exec.LookPath(name); err == nil {
+			return []string{name}, nil
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, errors.New("no editor found, set \"editor\" in the config, $VISUAL or $EDITOR")
+}
+
+// Command fills the placeholders of args for path and line (1 based)
+func Command(args []string, path string, line int) *exec.Cmd {
+	line = max(line, 1)
+	hasFile, hasLine := false, false
+	filled := make([]string, 0, len(args)+2)
+	for _, arg := range args {
+		hasFile = hasFile || strings.Contains(arg, "{file}")
+		hasLine = hasLine || strings.Contains(arg, "{line}")
+		arg = strings.ReplaceAll(arg, "{file}", path)
+		arg = strings.ReplaceAll(arg, "{line}", strconv.Itoa(line))
+		filled = append(filled, arg)
+	}
+	if !hasFile {
+		name := strings.TrimSuffix(filepath.Base(args[0]), ".exe")
+		if !hasLine && line > 1 && plusLine[name] {
+			filled = append(filled, "+"+strconv.Itoa(line))
+		}
+		filled = append(filled, path)
+	}
+	return exec.Command(filled[0], filled[1:]...)
+}
+
+// Split breaks a command line at whitespace, quotes keep spaces inside an argument
+func Split(command string) ([]string, error) {
+	args := make([]string, 0)
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unclosed quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
@@ -0,0 +1,59 @@
+package editor
+
+import (
+	"slices"
+	"testing"
+)
+
+// tests splitting commands with quotes
+func TestSplit(t *testing.T) {
+	args, err := Split(`code --wait  "my dir/file" 'a b'`)
+	if err != nil || !slices.Equal(args, []string{"code", "--wait", "my dir/file", "a b"}) {
+		t.Errorf("unexpected split %q, %v", args, err)
+	}
+	if _, err := Split(`vim "open`); err == nil {
+		t.Error("expected an error for an unclosed quote")
+	}
+	if _, err := Split("  "); err == nil {
+		t.Error("expected an error for an empty command")
+	}
+}
+
+// tests filling in the file and line
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		line int
+		want []string
+	}{
+		{[]string{"code", "--wait"}, 7, []string{"code", "--wait", "a.md"}},
+		{[]string{"code", "--goto", "{file}:{line}"}, 7, []string{"code", "--goto", "a.md:7"}},
+		{[]string{"nvim", "+{line}"}, 7, []string{"nvim", "+7", "a.md"}},
+		{[]string{"/usr/bin/vim"}, 7, []string{"/usr/bin/vim", "+7", "a.md"}},
+		{[]string{"vim"}, 1, []string{"vim", "a.md"}},
+	}
+	for _, tt := range tests {
+		if got := Command(tt.args, "a.md", tt.line).Args; !slices.Equal(got, tt.want) {
+			t.Errorf("Command(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+// tests the order editors are picked in
+func TestResolve(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sh -c")
+	if args, err := Resolve(""); err != nil || !slices.Equal(args, []string{"sh", "-c"}) {
+		t.Errorf("expected $EDITOR, got %q, %v", args, err)
+	}
+	if args, err := Resolve("ls -l"); err != nil || args[0] != "ls" {
+		t.Errorf("expected the configured editor first, got %q, %v", args, err)
+	}
+
+	// a missing editor falls through, the error names it when nothing else is found
+	t.Setenv("EDITOR", "")
+	t.Setenv("PATH", t.TempDir())
+	if _, err := Resolve("no-such-editor"); err == nil {
+		t.Error("expected an error without any editor")
+	}
+}
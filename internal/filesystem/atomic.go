@@ -0,0 +1,147 @@
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// FileWrite is new content for an existing file
+type FileWrite struct {
+	Path string
+	Data []byte
+}
+
+// WriteFileAtomic replaces the content of path without ever leaving it half
+// written: the data goes to a synced temp file that is then renamed over it.
+// an existing file keeps its mode, a new one gets 0644. links are followed.
+func WriteFileAtomic(path string, data []byte) error {
+	target, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, os.ErrNotExist) {
+		target = path
+	} else if err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(target); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := writeTemp(target, data, mode)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(target))
+	return nil
+}
+
+// a write staged in a temp file next to its target, with what to put back
+type stagedWrite struct {
+	target string
+	tmp    string
+	old    []byte
+	mode   os.FileMode
+}
+
+// WriteAll replaces several files so that either all of them change or none
+// do. everything goes to temp files next to the originals first and only then
+// gets renamed over them, should a rename still fail the files already
+// replaced are put back. links are followed, the file behind them is replaced.
+func WriteAll(writes []FileWrite) error {
+	staged := make([]stagedWrite, 0, len(writes))
+	cleanup := func() {
+		for _, s := range staged {
+			os.Remove(s.tmp)
+		}
+	}
+
+	for _, w := range writes {
+		target, err := filepath.EvalSymlinks(w.Path)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		info, err := os.Stat(target)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		old, err := os.ReadFile(target)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		tmp, err := writeTemp(target, w.Data, info.Mode().Perm())
+		if err != nil {
+			cleanup()
+			return err
+		}
+		staged = append(staged, stagedWrite{target: target, tmp: tmp, old: old, mode: info.Mode().Perm()})
+	}
+
+	for i, s := range staged {
+		if err := os.Rename(s.tmp, s.target); err != nil {
+			rollbackErr := restore(staged[:i])
+			cleanup()
+			return errors.Join(err, rollbackErr)
+		}
+	}
+	for _, s := range staged {
+		syncDir(filepath.Dir(s.target))
+	}
+	return nil
+}
+
+// puts the old content back into files already replaced
+func restore(done []stagedWrite) error {
+	var errs []error
+	for _, s := range done {
+		tmp, err := writeTemp(s.target, s.old, s.mode)
+		if err == nil {
+			err = os.Rename(tmp, s.target)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writes data to a hidden temp file in the same folder as path, synced so a
+// rename over path never leaves a half written file behind
+func writeTemp(path string, data []byte, mode os.FileMode) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, mode)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
+
+// makes a rename in dir stick after a crash. not every system can sync a
+// folder, the rename happened either way so errors are ignored
+func syncDir(dir string) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	f.Sync()
+	f.Close()
+}
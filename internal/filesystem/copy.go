@@ -0,0 +1,94 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CopyPath copies a file, or a folder with everything in it, refusing to
+// overwrite. Links are copied as links so a link loop can't make it run forever.
+func CopyPath(src, dst string) error {
+	if src == "" || dst == "" {
+		return errors.New("path cannot be empty")
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return errors.New("destination already exists")
+	}
+	if dst == src || strings.HasPrefix(dst, src+string(filepath.Separator)) {
+		return errors.New("cannot copy a folder into itself")
+	}
+	return copyPath(src, dst)
+}
+
+func copyPath(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case info.IsDir():
+		if err := os.Mkdir(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyPath(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return copyFile(src, dst, info.Mode().Perm())
+	}
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// CopyName is a free path next to path for a copy of it:
+// "weekly.md" gives "weekly copy.md", then "weekly copy 2.md" and so on
+func CopyName(path string) string {
+	dir, base := filepath.Split(path)
+	ext := ""
+	if info, err := os.Lstat(path); err != nil || !info.IsDir() {
+		ext = filepath.Ext(base) // folders named like "v1.2" keep their dot
+	}
+	stem := strings.TrimSuffix(base, ext)
+	for i := 1; ; i++ {
+		name := stem + " copy" + ext
+		if i > 1 {
+			name = fmt.Sprintf("%s copy %d%s", stem, i, ext)
+		}
+		candidate := filepath.Join(dir, name)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package filesystem
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// CreatedTime gives the birth time of a file, mtime if it is not available
+func CreatedTime(path string, info os.FileInfo) time.Time {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.ModTime()
+	}
+	return time.Unix(stat.Birthtimespec.Sec, stat.Birthtimespec.Nsec)
+}
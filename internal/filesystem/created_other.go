@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package filesystem
+
+import (
+	"os"
+	"time"
+)
+
+// CreatedTime falls back to mtime where there is no portable birth time
+func CreatedTime(path string, info os.FileInfo) time.Time {
+	return info.ModTime()
+}
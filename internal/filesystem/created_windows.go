@@ -0,0 +1,16 @@
+package filesystem
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// CreatedTime gives the creation time of a file, mtime if it is not available
+func CreatedTime(path string, info os.FileInfo) time.Time {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return info.ModTime()
+	}
+	return time.Unix(0, data.CreationTime.Nanoseconds())
+}
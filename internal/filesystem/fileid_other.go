@@ -0,0 +1,19 @@
+//go:build !unix
+
+package filesystem
+
+import "path/filepath"
+
+// no inodes to go by, the fully resolved path identifies a folder just as well
+type fileID struct {
+	path string
+}
+
+func idOf(path string) (fileID, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileID{}, err
+	}
+	abs, err := filepath.Abs(resolved)
+	return fileID{path: abs}, err
+}
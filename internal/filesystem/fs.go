@@ -34,9 +34,26 @@ func DeletePath(path string) error {
 		return errors.New("path cannot be empty")
 	}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	if _, err := os.Lstat(path); os.IsNotExist(err) { // a link itself, not what it points to
 		return errors.New("path does not exist")
 	}
 
 	return os.RemoveAll(path)
 }
+
+// MovePath moves a file or folder to a new full path, refusing to overwrite
+func MovePath(src, dst string) error {
+	if src == "" || dst == "" {
+		return errors.New("path cannot be empty")
+	}
+
+	if _, err := os.Lstat(src); os.IsNotExist(err) {
+		return errors.New("path does not exist")
+	}
+
+	if _, err := os.Lstat(dst); err == nil {
+		return errors.New("destination already exists")
+	}
+
+	return os.Rename(src, dst)
+}
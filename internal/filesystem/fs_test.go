@@ -4,6 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"mend/styles"
 )
 
 // tests creating a file in a temp dir
@@ -107,3 +110,270 @@ func TestDeletePath(t *testing.T) {
 		t.Error("expected error for empty path, got nil")
 	}
 }
+
+// tests that the watcher reports creates and removes in nested folders
+func TestWatcher(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fs_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	subDir := filepath.Join(tmpDir, "subdir")
+	os.Mkdir(subDir, 0755)
+
+	w, err := NewWatcher(tmpDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	next := func() Event {
+		ch := make(chan Event, 1)
+		go func() {
+			ev, _ := w.Next()
+			ch <- ev
+		}()
+		select {
+		case ev := <-ch:
+			return ev
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+		return Event{}
+	}
+
+	filePath := filepath.Join(subDir, "note.md")
+	os.WriteFile(filePath, []byte("data"), 0644)
+	if ev := next(); ev.Op != EventCreate || ev.Path != filePath {
+		t.Errorf("expected create of %s, got %+v", filePath, ev)
+	}
+
+	// dot files are not reported, so the next event is the removal
+	os.WriteFile(filepath.Join(subDir, ".hidden"), []byte("data"), 0644)
+	os.Remove(filePath)
+	if ev := next(); ev.Op != EventRemove || ev.Path != filePath {
+		t.Errorf("expected remove of %s, got %+v", filePath, ev)
+	}
+}
+
+// tests moving a file without overwriting
+func TestMovePath(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fs_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "a.md")
+	dst := filepath.Join(tmpDir, "b.md")
+	os.WriteFile(src, []byte("data"), 0644)
+
+	if err := MovePath(src, dst); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if _, err := os.Stat(dst); err != nil {
+		t.Error("file was not moved")
+	}
+
+	// test existing destination
+	os.WriteFile(src, []byte("data"), 0644)
+	if err := MovePath(src, dst); err == nil {
+		t.Error("expected error for existing destination, got nil")
+	}
+
+	// test missing source
+	if err := MovePath(filepath.Join(tmpDir, "nonexistent"), dst); err == nil {
+		t.Error("expected error for nonexistent path, got nil")
+	}
+}
+
+// tests classifying files by extension
+func TestKindOf(t *testing.T) {
+	tests := map[string]FileKind{
+		"note.md":          KindNote,
+		"dir/photo.JPG":    KindImage,
+		"diagram.svg":      KindImage,
+		"paper.pdf":        KindPDF,
+		"script.sh":        KindOther,
+		"no_extension":     KindOther,
+		"archive.md.bak":   KindOther,
+		"nested/a/b/c.png": KindImage,
+	}
+	for path, want := range tests {
+		if got := KindOf(path); got != want {
+			t.Errorf("KindOf(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// tests that kind names are the ones styles picks icons by
+func TestKindNames(t *testing.T) {
+	tests := map[FileKind]styles.Kind{
+		KindNote:  styles.KindNote,
+		KindImage: styles.KindImage,
+		KindPDF:   styles.KindPDF,
+		KindOther: styles.KindOther,
+	}
+	for kind, want := range tests {
+		if got := styles.Kind(kind.String()); got != want {
+			t.Errorf("%v.String() = %q, want %q", kind, got, want)
+		}
+	}
+}
+
+// tests resolving links and refusing to walk back into a folder
+func TestReadDirSymlinks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fs_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.Mkdir(filepath.Join(tmpDir, "real"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "note.md"), []byte(""), 0644)
+	if err := os.Symlink(filepath.Join(tmpDir, "real"), filepath.Join(tmpDir, "dirlink")); err != nil {
+		t.Skip("symlinks not supported")
+	}
+	os.Symlink(filepath.Join(tmpDir, "note.md"), filepath.Join(tmpDir, "filelink.md"))
+	os.Symlink(filepath.Join(tmpDir, "missing"), filepath.Join(tmpDir, "broken"))
+	os.Symlink(tmpDir, filepath.Join(tmpDir, "real", "up"))
+
+	entries, err := ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]Entry)
+	for _, e := range entries {
+		byName[e.Name] = e
+	}
+	if e := byName["dirlink"]; !e.IsDir || !e.Symlink {
+		t.Errorf("expected dirlink to be a linked folder, got %+v", e)
+	}
+	if e := byName["filelink.md"]; e.IsDir || !e.Symlink {
+		t.Errorf("expected filelink.md to be a linked file, got %+v", e)
+	}
+	if e := byName["broken"]; !e.Broken || e.IsDir {
+		t.Errorf("expected broken link to be a broken file, got %+v", e)
+	}
+
+	guard := NewLoopGuard()
+	leave, ok := guard.Enter(tmpDir)
+	if !ok {
+		t.Fatal("expected first visit to be allowed")
+	}
+	if _, ok := guard.Enter(filepath.Join(tmpDir, "real", "up")); ok {
+		t.Error("expected link back to an active folder to be refused")
+	}
+	leave()
+	if _, ok := guard.Enter(filepath.Join(tmpDir, "real", "up")); !ok {
+		t.Error("expected folder to be enterable again after leaving")
+	}
+}
+
+func TestCopyPath(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fs_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src := filepath.Join(tmpDir, "weekly")
+	os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	os.WriteFile(filepath.Join(src, "sub", "review.md"), []byte("# review"), 0644)
+
+	dst := CopyName(src)
+	if filepath.Base(dst) != "weekly copy" {
+		t.Fatalf("CopyName() = %s", dst)
+	}
+	if err := CopyPath(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dst, "sub", "review.md"))
+	if err != nil || string(data) != "# review" {
+		t.Errorf("expected nested file to be copied, got %q, %v", data, err)
+	}
+	if err := CopyPath(src, dst); err == nil {
+		t.Error("expected copy onto an existing path to fail")
+	}
+	if err := CopyPath(src, filepath.Join(src, "sub", "again")); err == nil {
+		t.Error("expected copy into itself to fail")
+	}
+
+	note := filepath.Join(tmpDir, "a.md")
+	os.WriteFile(note, []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "a copy.md"), []byte(""), 0644)
+	if got := filepath.Base(CopyName(note)); got != "a copy 2.md" {
+		t.Errorf("CopyName() = %s, want a copy 2.md", got)
+	}
+}
+
+// tests replacing several files at once and leaving them alone on failure
+func TestWriteAll(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fs_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	a := filepath.Join(tmpDir, "a.md")
+	b := filepath.Join(tmpDir, "b.md")
+	os.WriteFile(a, []byte("old a"), 0600)
+	os.WriteFile(b, []byte("old b"), 0644)
+
+	err = WriteAll([]FileWrite{{Path: a, Data: []byte("new a")}, {Path: b, Data: []byte("new b")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(a); string(data) != "new a" {
+		t.Errorf("expected a rewritten, got %q", data)
+	}
+	if info, _ := os.Stat(a); info.Mode().Perm() != 0600 {
+		t.Errorf("expected the mode kept, got %v", info.Mode().Perm())
+	}
+
+	// a missing file stops everything before anything is replaced
+	err = WriteAll([]FileWrite{{Path: a, Data: []byte("newer a")}, {Path: filepath.Join(tmpDir, "gone.md"), Data: nil}})
+	if err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	if data, _ := os.ReadFile(a); string(data) != "new a" {
+		t.Errorf("expected a untouched, got %q", data)
+	}
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 2 {
+		t.Errorf("expected the temp files cleaned up, got %d entries", len(entries))
+	}
+}
+
+// tests replacing a file in place, keeping its mode
+func TestWriteFileAtomic(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fs_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "a.md")
+	os.WriteFile(path, []byte("old"), 0600)
+	if err := WriteFileAtomic(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("expected new content, got %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("expected the mode kept, got %v", info.Mode().Perm())
+	}
+
+	// a new file gets the usual mode
+	fresh := filepath.Join(tmpDir, "b.md")
+	if err := WriteFileAtomic(fresh, []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(fresh); info.Mode().Perm() != 0644 {
+		t.Errorf("expected 0644 for a new file, got %v", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 2 {
+		t.Errorf("expected no temp files left, got %d entries", len(entries))
+	}
+}
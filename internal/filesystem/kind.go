@@ -0,0 +1,71 @@
+package filesystem
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// what a file is as far as mend cares, decides icon, preview and how it opens
+type FileKind int
+
+const (
+	KindNote FileKind = iota
+	KindImage
+	KindPDF
+	KindOther
+)
+
+var imageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true,
+	".webp": true, ".svg": true, ".bmp": true, ".ico": true,
+}
+
+// KindOf classifies a file by its extension, content is never looked at
+func KindOf(path string) FileKind {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".md":
+		return KindNote
+	case ext == ".pdf":
+		return KindPDF
+	case imageExtensions[ext]:
+		return KindImage
+	}
+	return KindOther
+}
+
+func (k FileKind) String() string {
+	switch k {
+	case KindNote:
+		return "note"
+	case KindImage:
+		return "image"
+	case KindPDF:
+		return "pdf"
+	}
+	return "file"
+}
+
+// OpenExternally hands a file to whatever the OS opens it with, without waiting
+func OpenExternally(path string) error {
+	if path == "" {
+		return errors.New("path cannot be empty")
+	}
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", path)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	default:
+		c = exec.Command("xdg-open", path)
+	}
+	if err := c.Start(); err != nil {
+		return err
+	}
+	go c.Wait() // reap it, the opener usually exits right away
+	return nil
+}
@@ -0,0 +1,68 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Entry is a folder entry with symlinks resolved, so a link to a folder is a folder
+type Entry struct {
+	Name    string
+	Path    string
+	IsDir   bool
+	Symlink bool
+	Broken  bool // a link pointing nowhere, listed as a file
+}
+
+// ReadDir lists a folder the way everything that walks the notes sees it.
+// Links are followed to find out what they point to but keep their own path.
+func ReadDir(path string) ([]Entry, error) {
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(dirEntries))
+	for _, d := range dirEntries {
+		e := Entry{
+			Name:  d.Name(),
+			Path:  filepath.Join(path, d.Name()),
+			IsDir: d.IsDir(),
+		}
+		if d.Type()&os.ModeSymlink != 0 {
+			e.Symlink = true
+			info, err := os.Stat(e.Path)
+			if err != nil {
+				e.Broken = true
+			} else {
+				e.IsDir = info.IsDir()
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// LoopGuard keeps track of the folders on the way down a walk, by device and
+// inode so two paths to the same folder are the same. A link back up into
+// one of them would be a loop and is not entered.
+type LoopGuard struct {
+	active map[fileID]bool
+}
+
+func NewLoopGuard() *LoopGuard {
+	return &LoopGuard{active: make(map[fileID]bool)}
+}
+
+// Enter marks a folder as being walked, ok is false if it already is. leave
+// has to be called once the folder is done.
+func (g *LoopGuard) Enter(path string) (leave func(), ok bool) {
+	id, err := idOf(path)
+	if err != nil {
+		return func() {}, true // can't tell, unreadable folders fail on their own anyway
+	}
+	if g.active[id] {
+		return func() {}, false
+	}
+	g.active[id] = true
+	return func() { delete(g.active, id) }, true
+}
@@ -0,0 +1,151 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// kinds of changes the rest of the app cares about, fsnotify has a few more
+// but a write doesn't change the shape of the tree
+type EventOp int
+
+const (
+	EventCreate EventOp = iota
+	EventRemove         // also covers the old path of a rename
+)
+
+type Event struct {
+	Op   EventOp
+	Path string
+}
+
+// Watcher watches root folders recursively. fsnotify only watches single
+// directories so every sub folder is added as well, and new ones as they appear.
+type Watcher struct {
+	roots   []string
+	fsw     *fsnotify.Watcher
+	events  chan Event
+	ignored func(path string, isDir bool) bool
+}
+
+// ignored leaves paths out on top of dot files, nil for nothing
+func NewWatcher(root string, ignored func(path string, isDir bool) bool) (*Watcher, error) {
+	return NewWorkspaceWatcher([]string{root}, ignored)
+}
+
+// NewWorkspaceWatcher watches several roots, events of all of them come out of Next
+func NewWorkspaceWatcher(roots []string, ignored func(path string, isDir bool) bool) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		roots:   roots,
+		fsw:     fsw,
+		events:  make(chan Event, 64),
+		ignored: ignored,
+	}
+	for _, root := range roots {
+		if err := w.addRecursive(root); err != nil {
+			fsw.Close()
+			return nil, err
+		}
+	}
+	go w.run()
+	return w, nil
+}
+
+// Next blocks until the next relevant change, ok is false once the watcher is closed
+func (w *Watcher) Next() (Event, bool) {
+	ev, ok := <-w.events
+	return ev, ok
+}
+
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+func (w *Watcher) run() {
+	defer close(w.events)
+	for {
+		select {
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if w.isHidden(ev.Name) {
+				continue
+			}
+			switch {
+			case ev.Has(fsnotify.Create):
+				info, err := os.Stat(ev.Name)
+				if err == nil && w.ignored != nil && w.ignored(ev.Name, info.IsDir()) {
+					continue
+				}
+				if err == nil && info.IsDir() {
+					// files created before the watch was added would be missed otherwise,
+					// the tree walks the new folder itself so no events needed for them
+					_ = w.addRecursive(ev.Name)
+				}
+				w.events <- Event{Op: EventCreate, Path: ev.Name}
+			case ev.Has(fsnotify.Remove), ev.Has(fsnotify.Rename):
+				w.events <- Event{Op: EventRemove, Path: ev.Name}
+			}
+		case _, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			// nothing useful to do with these, an overflow just means a missed event
+		}
+	}
+}
+
+// follows folder links like the tree does, inotify watches what a link points to
+func (w *Watcher) addRecursive(path string) error {
+	return w.addTree(path, NewLoopGuard())
+}
+
+func (w *Watcher) addTree(path string, guard *LoopGuard) error {
+	leave, ok := guard.Enter(path)
+	if !ok {
+		return nil // a link back up, already watched
+	}
+	defer leave()
+	if err := w.fsw.Add(path); err != nil {
+		return err
+	}
+	entries, err := ReadDir(path)
+	if err != nil {
+		return nil // skip unreadable folders
+	}
+	for _, e := range entries {
+		if !e.IsDir || strings.HasPrefix(e.Name, ".") {
+			continue
+		}
+		if w.ignored != nil && w.ignored(e.Path, true) {
+			continue
+		}
+		_ = w.addTree(e.Path, guard)
+	}
+	return nil
+}
+
+// same rule as the tree: anything under a dot folder or a dot file is not shown
+func (w *Watcher) isHidden(path string) bool {
+	for _, root := range w.roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		for _, part := range strings.Split(rel, string(filepath.Separator)) {
+			if strings.HasPrefix(part, ".") && part != "." {
+				return true
+			}
+		}
+		return false
+	}
+	return true // not under any root
+}
@@ -0,0 +1,157 @@
+/*
+yaml frontmatter: the --- block some notes start with. it is metadata about
+the note, never part of its cards. nothing here touches the ui, the note view,
+search and review all read notes through it.
+*/
+package frontmatter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Metadata is what a note says about itself in its frontmatter
+type Metadata struct {
+	Title      string
+	Tags       []string
+	Aliases    []string
+	Created    time.Time // zero if missing or not a date
+	SkipReview bool      // srs: false, the note is never reviewed
+	Deck       string    // srs: <deck> or deck: <deck>, empty for no deck
+}
+
+// dates are written by hand so take the usual shapes
+var createdLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+
+// Split separates a leading --- yaml block from the rest of a note.
+// fm is the yaml between the fences, body starts right after the closing fence
+// line. ok is false when the note has no (closed) block.
+func Split(source []byte) (fm, body []byte, ok bool) {
+	first, rest, found := bytes.Cut(source, []byte("\n"))
+	if !found || strings.TrimRight(string(first), " \t\r") != "---" {
+		return nil, source, false
+	}
+	offset := len(first) + 1
+	for len(rest) > 0 {
+		line, next, _ := bytes.Cut(rest, []byte("\n"))
+		trimmed := strings.TrimRight(string(line), " \t\r")
+		if trimmed == "---" || trimmed == "..." {
+			end := offset + len(line)
+			if end < len(source) {
+				end++ // the newline after the fence
+			}
+			return source[len(first)+1 : offset], source[end:], true
+		}
+		offset += len(line) + 1
+		rest = next
+	}
+	return nil, source, false
+}
+
+// Parse reads the frontmatter of a note, a note without one (or with broken
+// yaml) just has no metadata
+func Parse(source []byte) Metadata {
+	fm, _, ok := Split(source)
+	if !ok {
+		return Metadata{}
+	}
+	return ParseBlock(fm)
+}
+
+// ParseBlock reads the yaml between the fences, as Split gives it
+func ParseBlock(fm []byte) Metadata {
+	// loose types, people write tags: a, b as often as a list
+	var raw struct {
+		Title   any `yaml:"title"`
+		Tags    any `yaml:"tags"`
+		Aliases any `yaml:"aliases"`
+		Created any `yaml:"created"`
+		SRS     any `yaml:"srs"`
+		Deck    any `yaml:"deck"`
+	}
+	if err := yaml.Unmarshal(fm, &raw); err != nil {
+		return Metadata{}
+	}
+
+	meta := Metadata{
+		Title:   scalarString(raw.Title),
+		Aliases: stringList(raw.Aliases, ","),
+		Created: parseCreated(raw.Created),
+		Deck:    scalarString(raw.Deck),
+	}
+	for _, tag := range stringList(raw.Tags, ", ") {
+		if tag = NormalizeTag(tag); tag != "" {
+			meta.Tags = append(meta.Tags, tag)
+		}
+	}
+	switch srs := raw.SRS.(type) {
+	case bool:
+		meta.SkipReview = !srs
+	case string:
+		meta.Deck = strings.TrimSpace(srs)
+	}
+	return meta
+}
+
+func scalarString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case time.Time:
+		return v.Format("2006-01-02")
+	case []any, map[string]any:
+		return "" // not a scalar
+	}
+	return fmt.Sprint(v)
+}
+
+// a list, or a single string split on any of seps
+func stringList(v any, seps string) []string {
+	var list []string
+	switch v := v.(type) {
+	case string:
+		list = strings.FieldsFunc(v, func(r rune) bool { return strings.ContainsRune(seps, r) })
+	case []any:
+		for _, item := range v {
+			list = append(list, scalarString(item))
+		}
+	}
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func parseCreated(v any) time.Time {
+	switch v := v.(type) {
+	case time.Time:
+		return v
+	case string:
+		for _, layout := range createdLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// NormalizeTag drops the # and stray slashes, numbers alone (#1 in a list) are
+// no tag. inline #tags go through it too so both kinds compare equal
+func NormalizeTag(tag string) string {
+	tag = strings.Trim(strings.TrimPrefix(strings.TrimSpace(tag), "#"), "/")
+	if strings.IndexFunc(tag, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		return ""
+	}
+	return tag
+}
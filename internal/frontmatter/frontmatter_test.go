@@ -0,0 +1,42 @@
+package frontmatter
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// tests reading the typed metadata out of the yaml block
+func TestParse(t *testing.T) {
+	source := []byte("---\ntitle: Go notes\ntags: [lang/go, \"#review\"]\naliases: Golang, go lang\n" +
+		"created: 2024-03-01\nsrs: false\n---\n# Heading\n")
+
+	meta := Parse(source)
+	if meta.Title != "Go notes" {
+		t.Errorf("expected title, got %q", meta.Title)
+	}
+	if strings.Join(meta.Tags, ",") != "lang/go,review" {
+		t.Errorf("expected tags, got %v", meta.Tags)
+	}
+	if strings.Join(meta.Aliases, ",") != "Golang,go lang" {
+		t.Errorf("expected aliases split on commas only, got %v", meta.Aliases)
+	}
+	if y, m, d := meta.Created.Date(); y != 2024 || m != time.March || d != 1 {
+		t.Errorf("expected created date, got %v", meta.Created)
+	}
+	if !meta.SkipReview || meta.Deck != "" {
+		t.Errorf("expected srs: false to skip review, got %+v", meta)
+	}
+
+	meta = Parse([]byte("---\nsrs: spanish\n---\n"))
+	if meta.SkipReview || meta.Deck != "spanish" {
+		t.Errorf("expected srs to name a deck, got %+v", meta)
+	}
+
+	if meta := Parse([]byte("---\ntitle: [broken\n---\n")); meta.Title != "" {
+		t.Errorf("expected broken yaml to give no metadata, got %+v", meta)
+	}
+	if meta := Parse([]byte("no frontmatter\n---\n")); meta.Title != "" {
+		t.Errorf("expected no metadata, got %+v", meta)
+	}
+}
@@ -0,0 +1,53 @@
+/*
+reading the frontmatter of a note on disk, for the tree, links and anything
+else that needs aliases or flags without opening the note.
+*/
+
+package frontmatter
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	modTime time.Time
+	meta    Metadata
+}
+
+// reading every note for its metadata is not cheap, cached until the file changes
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]cacheEntry)
+)
+
+// Read gives the frontmatter of the note at path, empty for anything that isn't one
+func Read(path string) Metadata {
+	if !strings.HasSuffix(path, ".md") {
+		return Metadata{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Metadata{}
+	}
+
+	cacheMu.Lock()
+	entry, ok := cache[path]
+	cacheMu.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) {
+		return entry.meta
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Metadata{}
+	}
+	entry = cacheEntry{modTime: info.ModTime(), meta: Parse(data)}
+
+	cacheMu.Lock()
+	cache[path] = entry
+	cacheMu.Unlock()
+	return entry.meta
+}
@@ -0,0 +1,162 @@
+/*
+just enough git for decorating the tree and committing after changes. it
+shells out to the git binary so it works offline with whatever the user
+already has set up (identity, hooks, signing). a root can be a sub folder of a
+repo, everything here is limited to the root.
+*/
+
+package git
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Status of a path, higher wins when a folder sums up what's inside it
+type Status int
+
+const (
+	Unchanged Status = iota
+	Staged
+	Untracked
+	Modified
+)
+
+func (s Status) Marker() string {
+	switch s {
+	case Staged:
+		return "S"
+	case Untracked:
+		return "U"
+	case Modified:
+		return "M"
+	}
+	return ""
+}
+
+// mend keeps sessions, versions and drafts in .mend in every root, that's not notes
+const excludeMend = ":(exclude).mend"
+
+type Repo struct {
+	root   string
+	prefix string // root relative to the top of the repo, with a trailing / unless empty
+}
+
+// Open gives the repo a root is in, an error if it isn't in one or git is missing
+func Open(root string) (*Repo, error) {
+	out, err := run(root, "rev-parse", "--is-inside-work-tree", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) == 0 || lines[0] != "true" {
+		return nil, errors.New("not inside a git work tree")
+	}
+	prefix := ""
+	if len(lines) > 1 {
+		prefix = lines[1]
+	}
+	return &Repo{root: root, prefix: prefix}, nil
+}
+
+func (r *Repo) Root() string {
+	return r.root
+}
+
+// Status lists every changed file under the root by absolute path
+func (r *Repo) Status() (map[string]Status, error) {
+	out, err := run(r.root, "status", "--porcelain=v1", "-z", "--untracked-files=all", "--", ".", excludeMend)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(map[string]Status)
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		x, y, path := entry[0], entry[1], entry[3:]
+		if x == 'R' || x == 'C' {
+			i++ // the source of a rename or copy comes next, it's not here anymore
+		}
+		var status Status
+		switch {
+		case x == '?' && y == '?':
+			status = Untracked
+		case y != ' ':
+			status = Modified // changed in the work tree, staged or not
+		default:
+			status = Staged
+		}
+		rel, ok := strings.CutPrefix(path, r.prefix)
+		if !ok {
+			continue
+		}
+		statuses[filepath.Join(r.root, filepath.FromSlash(rel))] = status
+	}
+	return statuses, nil
+}
+
+// Commit commits what changed at paths (files or folders, gone or not) and
+// nothing else, other edits and whatever is staged already stay as they are.
+// paths outside the root and mend's own .mend folder are left out, false if
+// there was nothing to commit
+func (r *Repo) Commit(message string, paths []string) (bool, error) {
+	specs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		rel, err := filepath.Rel(r.root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		spec := ":(literal)" + filepath.ToSlash(rel)
+		if _, err := os.Lstat(path); err != nil {
+			// add complains about paths that aren't there, rm takes them out of the index
+			if _, err := run(r.root, "rm", "--cached", "-r", "-q", "--ignore-unmatch", "--", spec); err != nil {
+				return false, err
+			}
+		} else if _, err := run(r.root, "add", "-A", "--", spec, excludeMend); err != nil {
+			return false, err
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return false, nil
+	}
+	out, err := run(r.root, append([]string{"diff", "--cached", "--name-only", "-z", "--relative", "--"}, append(specs, excludeMend)...)...)
+	if err != nil {
+		return false, err
+	}
+	changed := make([]string, 0)
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			changed = append(changed, ":(literal)"+name)
+		}
+	}
+	if len(changed) == 0 {
+		return false, nil
+	}
+	if _, err := run(r.root, append([]string{"commit", "-q", "-m", message, "--"}, changed...)...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
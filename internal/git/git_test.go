@@ -0,0 +1,140 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// makes a repo with an identity so commits work anywhere, skips without git
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	tmpDir, err := os.MkdirTemp("", "mend_git_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		if _, err := run(tmpDir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmpDir
+}
+
+// tests status markers and committing, with the root a sub folder of the repo
+func TestStatusAndCommit(t *testing.T) {
+	repoDir := newRepo(t)
+	root := filepath.Join(repoDir, "notes")
+	os.Mkdir(root, 0755)
+	os.WriteFile(filepath.Join(repoDir, "outside.md"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(root, "a.md"), []byte("a"), 0644)
+
+	repo, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := repo.Commit("first", []string{filepath.Join(root, "a.md"), filepath.Join(repoDir, "outside.md")})
+	if err != nil || !committed {
+		t.Fatalf("Commit() = %v, %v", committed, err)
+	}
+
+	// only the root is committed
+	statuses, _ := Open(repoDir)
+	all, _ := statuses.Status()
+	if all[filepath.Join(repoDir, "outside.md")] != Untracked {
+		t.Error("expected file outside the root to stay uncommitted")
+	}
+
+	os.WriteFile(filepath.Join(root, "a.md"), []byte("changed"), 0644)
+	os.WriteFile(filepath.Join(root, "b.md"), []byte("b"), 0644)
+	os.WriteFile(filepath.Join(root, "c.md"), []byte("c"), 0644)
+	run(root, "add", "c.md")
+
+	got, err := repo.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Status{
+		filepath.Join(root, "a.md"): Modified,
+		filepath.Join(root, "b.md"): Untracked,
+		filepath.Join(root, "c.md"): Staged,
+	}
+	if len(got) != len(want) {
+		t.Errorf("Status() = %v, want %v", got, want)
+	}
+	for path, status := range want {
+		if got[path] != status {
+			t.Errorf("status of %s = %v, want %v", filepath.Base(path), got[path], status)
+		}
+	}
+
+	paths := []string{filepath.Join(root, "a.md"), filepath.Join(root, "b.md"), filepath.Join(root, "c.md")}
+	if committed, _ := repo.Commit("second", paths); !committed {
+		t.Error("expected changes to be committed")
+	}
+	if committed, _ := repo.Commit("third", paths); committed {
+		t.Error("expected nothing left to commit")
+	}
+}
+
+// tests that only the given paths are committed, deletions included, and
+// never what mend keeps in .mend
+func TestCommitOnlyPaths(t *testing.T) {
+	root := newRepo(t)
+	os.WriteFile(filepath.Join(root, "a.md"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(root, "b.md"), []byte("b"), 0644)
+	repo, err := Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Commit("first", []string{filepath.Join(root, "a.md"), filepath.Join(root, "b.md")}); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Mkdir(filepath.Join(root, ".mend"), 0755)
+	os.WriteFile(filepath.Join(root, ".mend", "session.json"), []byte("{}"), 0644)
+	os.WriteFile(filepath.Join(root, "b.md"), []byte("edited elsewhere"), 0644)
+	os.Remove(filepath.Join(root, "a.md"))
+	os.WriteFile(filepath.Join(root, "c.md"), []byte("c"), 0644)
+	committed, err := repo.Commit("second", []string{filepath.Join(root, "a.md"), filepath.Join(root, "c.md"), filepath.Join(root, ".mend")})
+	if err != nil || !committed {
+		t.Fatalf("Commit() = %v, %v", committed, err)
+	}
+
+	out, err := run(root, "show", "--name-status", "--format=", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "D\ta.md\nA\tc.md\n" {
+		t.Errorf("committed %q, want a.md deleted and c.md added", out)
+	}
+	got, _ := repo.Status()
+	if got[filepath.Join(root, "b.md")] != Modified {
+		t.Error("expected the unrelated edit to stay uncommitted")
+	}
+	if _, ok := got[filepath.Join(root, ".mend", "session.json")]; ok {
+		t.Error("expected .mend to be left out of the status")
+	}
+}
+
+// tests that a folder outside any repo is reported as such
+func TestOpenNotRepo(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_git_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if _, err := Open(tmpDir); err == nil {
+		t.Error("expected an error outside a repo")
+	}
+}
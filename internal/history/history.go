@@ -0,0 +1,142 @@
+/*
+what notes were looked at and in which order. the recent list is most recently
+used first with every note once, back and forward work like a browser: going
+somewhere new after going back drops the forward list. every entry keeps the
+section the note was left on so coming back lands in the same place.
+*/
+
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	maxRecent = 50
+	maxBack   = 100
+)
+
+type Entry struct {
+	Path    string `json:"path"`
+	Section int    `json:"section"`
+}
+
+type History struct {
+	recent  []Entry
+	back    []Entry
+	forward []Entry
+}
+
+func New() *History {
+	return &History{}
+}
+
+// Restore builds a history from saved lists, entries whose file is gone are dropped
+func Restore(recent, back, forward []Entry) *History {
+	return &History{
+		recent:  existing(recent),
+		back:    existing(back),
+		forward: existing(forward),
+	}
+}
+
+// Navigate records leaving current (empty Path if nothing was open) for next
+func (h *History) Navigate(current, next Entry) {
+	if current.Path == next.Path {
+		h.touch(next)
+		return
+	}
+	if current.Path != "" {
+		h.touch(current)
+		h.back = push(h.back, current, maxBack)
+	}
+	h.forward = nil
+	h.touch(next)
+}
+
+// Back gives the entry to go back to, current is remembered for Forward
+func (h *History) Back(current Entry) (Entry, bool) {
+	return h.step(&h.back, &h.forward, current)
+}
+
+func (h *History) Forward(current Entry) (Entry, bool) {
+	return h.step(&h.forward, &h.back, current)
+}
+
+func (h *History) step(from, to *[]Entry, current Entry) (Entry, bool) {
+	for len(*from) > 0 {
+		next := (*from)[len(*from)-1]
+		*from = (*from)[:len(*from)-1]
+		if _, err := os.Stat(next.Path); err != nil || next.Path == current.Path {
+			continue // deleted since, or the note we're on
+		}
+		if current.Path != "" {
+			h.touch(current)
+			*to = push(*to, current, maxBack)
+		}
+		h.touch(next)
+		return next, true
+	}
+	return Entry{}, false
+}
+
+// Recent is most recently used first
+func (h *History) Recent() []Entry {
+	return h.recent
+}
+
+func (h *History) BackList() []Entry    { return h.back }
+func (h *History) ForwardList() []Entry { return h.forward }
+
+// Section is where a note was last left, 0 if it isn't in the history
+func (h *History) Section(path string) int {
+	for _, e := range h.recent {
+		if e.Path == path {
+			return e.Section
+		}
+	}
+	return 0
+}
+
+// Moved follows a file or folder that moved from one path to another
+func (h *History) Moved(from, to string) {
+	for _, list := range [][]Entry{h.recent, h.back, h.forward} {
+		for i := range list {
+			if list[i].Path == from {
+				list[i].Path = to
+			} else if rest, ok := strings.CutPrefix(list[i].Path, from+string(filepath.Separator)); ok {
+				list[i].Path = filepath.Join(to, rest)
+			}
+		}
+	}
+}
+
+// touch moves an entry to the front of the recent list
+func (h *History) touch(e Entry) {
+	h.recent = slices.DeleteFunc(h.recent, func(r Entry) bool { return r.Path == e.Path })
+	h.recent = slices.Insert(h.recent, 0, e)
+	if len(h.recent) > maxRecent {
+		h.recent = h.recent[:maxRecent]
+	}
+}
+
+func push(list []Entry, e Entry, limit int) []Entry {
+	list = append(list, e)
+	if len(list) > limit {
+		list = list[len(list)-limit:]
+	}
+	return list
+}
+
+func existing(entries []Entry) []Entry {
+	kept := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if _, err := os.Stat(e.Path); err == nil {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
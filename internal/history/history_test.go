@@ -0,0 +1,78 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tests back and forward like a browser, sections included
+func TestBackForward(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_history_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	a := filepath.Join(tmpDir, "a.md")
+	b := filepath.Join(tmpDir, "b.md")
+	c := filepath.Join(tmpDir, "c.md")
+	for _, p := range []string{a, b, c} {
+		os.WriteFile(p, []byte(""), 0644)
+	}
+
+	h := New()
+	h.Navigate(Entry{}, Entry{Path: a})
+	h.Navigate(Entry{Path: a, Section: 2}, Entry{Path: b})
+	h.Navigate(Entry{Path: b, Section: 1}, Entry{Path: c})
+
+	prev, ok := h.Back(Entry{Path: c})
+	if !ok || prev.Path != b || prev.Section != 1 {
+		t.Fatalf("Back() = %+v, %v, want b at section 1", prev, ok)
+	}
+	prev, _ = h.Back(Entry{Path: b, Section: 1})
+	if prev.Path != a || prev.Section != 2 {
+		t.Fatalf("Back() = %+v, want a at section 2", prev)
+	}
+	if _, ok := h.Back(Entry{Path: a}); ok {
+		t.Error("expected nothing further back")
+	}
+	next, ok := h.Forward(Entry{Path: a, Section: 2})
+	if !ok || next.Path != b {
+		t.Fatalf("Forward() = %+v, %v, want b", next, ok)
+	}
+
+	// going somewhere new drops the forward list
+	h.Navigate(Entry{Path: b}, Entry{Path: a})
+	if _, ok := h.Forward(Entry{Path: a}); ok {
+		t.Error("expected forward list to be cleared")
+	}
+
+	// deleted notes are skipped
+	os.Remove(b)
+	prev, _ = h.Back(Entry{Path: a})
+	if prev.Path == b {
+		t.Error("expected deleted note to be skipped")
+	}
+}
+
+// tests the recent list order and following moves
+func TestRecent(t *testing.T) {
+	h := New()
+	h.Navigate(Entry{}, Entry{Path: "/n/a.md"})
+	h.Navigate(Entry{Path: "/n/a.md", Section: 3}, Entry{Path: "/n/dir/b.md"})
+	h.Navigate(Entry{Path: "/n/dir/b.md"}, Entry{Path: "/n/a.md", Section: 3})
+
+	recent := h.Recent()
+	if len(recent) != 2 || recent[0].Path != "/n/a.md" || recent[1].Path != "/n/dir/b.md" {
+		t.Fatalf("Recent() = %+v", recent)
+	}
+	if h.Section("/n/a.md") != 3 {
+		t.Errorf("Section() = %d, want 3", h.Section("/n/a.md"))
+	}
+
+	h.Moved("/n/dir", "/n/other")
+	if h.Recent()[1].Path != filepath.Join("/n/other", "b.md") {
+		t.Errorf("expected moved folder to be followed, got %s", h.Recent()[1].Path)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+gitignore style ignore rules. one matcher per root is built from the root's
+.mendignore and, if enabled, its .gitignore, and every walker (tree, search
+index, fs watcher) asks the same matcher so they never disagree.
+
+supported: comments, blank lines, negation (!), dir only rules (trailing /),
+anchored rules (containing a /), *, ?, [...] classes and ** in any position.
+only the files at the root are read, nested ignore files are not. with
+several workspace roots each root keeps its own rules.
+*/
+
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	MendIgnoreFile = ".mendignore"
+	GitIgnoreFile  = ".gitignore"
+)
+
+type rule struct {
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// rules of one root
+type scope struct {
+	root  string
+	rules []rule
+}
+
+type Matcher struct {
+	scopes []*scope
+}
+
+// New reads the ignore files of a root, missing files simply add no rules
+func New(root string, honorGitignore bool) *Matcher {
+	return NewWorkspace([]string{root}, honorGitignore)
+}
+
+// NewWorkspace is New for several roots, a path only gets the rules of its own root
+func NewWorkspace(roots []string, honorGitignore bool) *Matcher {
+	m := &Matcher{}
+	for _, root := range roots {
+		s := &scope{root: root}
+		if honorGitignore {
+			s.addFile(filepath.Join(root, GitIgnoreFile))
+		}
+		// mend's own rules come last so they can override git's with !
+		s.addFile(filepath.Join(root, MendIgnoreFile))
+		m.scopes = append(m.scopes, s)
+	}
+	return m
+}
+
+// FromPatterns builds a matcher straight from pattern lines
+func FromPatterns(root string, patterns ...string) *Matcher {
+	s := &scope{root: root}
+	for _, p := range patterns {
+		s.addPattern(p)
+	}
+	return &Matcher{scopes: []*scope{s}}
+}
+
+func (m *scope) addFile(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m.addPattern(scanner.Text())
+	}
+}
+
+func (m *scope) addPattern(line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	r := rule{}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:] // escaped leading ! or #
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return
+	}
+	// a slash anywhere but the end ties the pattern to the root
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return // a pattern git would reject as well
+	}
+	r.re = re
+	m.rules = append(m.rules, r)
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				b.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// match applies the rules to one path relative to the root, last match wins
+func (m *scope) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// Ignored tells if a path under the root should be left out. Like git, a path
+// inside an ignored folder stays ignored whatever the rules say about it.
+// A nil matcher ignores nothing.
+func (m *Matcher) Ignored(path string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	for _, s := range m.scopes {
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		return s.ignored(rel, isDir)
+	}
+	return false
+}
+
+func (m *scope) ignored(rel string, isDir bool) bool {
+	if len(m.rules) == 0 {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := 1; i < len(parts); i++ {
+		if m.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.match(strings.Join(parts, "/"), isDir)
+}
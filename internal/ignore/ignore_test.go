@@ -0,0 +1,108 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tests gitignore style pattern semantics
+func TestIgnored(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "notes")
+	m := FromPatterns(root,
+		"# comment",
+		"",
+		"node_modules/",
+		"*.log",
+		"/build",
+		"docs/**/generated",
+		"!keep.log",
+		"tmp?",
+	)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"node_modules", true, true},
+		{"a/b/node_modules", true, true},
+		{"node_modules", false, false}, // dir only rule
+		{"node_modules/pkg/readme.md", false, true},
+		{"debug.log", false, true},
+		{"deep/inside/debug.log", false, true},
+		{"keep.log", false, false}, // negated
+		{"build", true, true},
+		{"sub/build", true, false}, // anchored to root
+		{"docs/generated", true, true},
+		{"docs/a/b/generated", true, true},
+		{"tmp1", false, true},
+		{"tmp12", false, false},
+		{"notes.md", false, false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(root, filepath.FromSlash(tt.path))
+		if got := m.Ignored(path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	var nilMatcher *Matcher
+	if nilMatcher.Ignored(filepath.Join(root, "debug.log"), false) {
+		t.Error("nil matcher should ignore nothing")
+	}
+}
+
+// tests reading rules from the ignore files of a root
+func TestNew(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_ignore_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, GitIgnoreFile), []byte("vendor/\n*.tmp\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, MendIgnoreFile), []byte("drafts/\n!important.tmp\n"), 0644)
+
+	m := New(tmpDir, true)
+	if !m.Ignored(filepath.Join(tmpDir, "vendor"), true) {
+		t.Error("expected .gitignore rule to apply")
+	}
+	if !m.Ignored(filepath.Join(tmpDir, "drafts"), true) {
+		t.Error("expected .mendignore rule to apply")
+	}
+	if m.Ignored(filepath.Join(tmpDir, "important.tmp"), false) {
+		t.Error("expected .mendignore to override .gitignore")
+	}
+
+	m = New(tmpDir, false)
+	if m.Ignored(filepath.Join(tmpDir, "vendor"), true) {
+		t.Error("expected .gitignore to be skipped")
+	}
+	if !m.Ignored(filepath.Join(tmpDir, "drafts"), true) {
+		t.Error("expected .mendignore rule to still apply")
+	}
+}
+
+// tests that every root of a workspace only gets its own rules
+func TestNewWorkspace(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_ignore_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	personal := filepath.Join(tmpDir, "personal")
+	shared := filepath.Join(tmpDir, "shared")
+	os.Mkdir(personal, 0755)
+	os.Mkdir(shared, 0755)
+	os.WriteFile(filepath.Join(personal, MendIgnoreFile), []byte("journal/\n"), 0644)
+
+	m := NewWorkspace([]string{personal, shared}, false)
+	if !m.Ignored(filepath.Join(personal, "journal"), true) {
+		t.Error("expected rule to apply in its own root")
+	}
+	if m.Ignored(filepath.Join(shared, "journal"), true) {
+		t.Error("expected rule to stay out of the other root")
+	}
+}
@@ -0,0 +1,72 @@
+package links
+
+import (
+	"slices"
+	"strings"
+)
+
+// Ref is a resolved link from one note to another
+type Ref struct {
+	From    string
+	To      string
+	Line    int    // 0 based line in From
+	Snippet string // the line the link is on
+}
+
+// Graph knows which notes link where, built by the search indexing pass
+type Graph struct {
+	out map[string][]Ref
+	in  map[string][]Ref
+}
+
+func NewGraph() *Graph {
+	return &Graph{
+		out: make(map[string][]Ref),
+		in:  make(map[string][]Ref),
+	}
+}
+
+// Refs resolves the wiki and markdown links of a note, links that go nowhere are left out
+func Refs(from string, source []byte, index *Index) []Ref {
+	lines := strings.Split(string(source), "\n")
+	refs := make([]Ref, 0)
+	for _, link := range append(Parse(source), ParseMarkdown(source)...) {
+		to, ok := index.ResolveLink(link, from)
+		if !ok {
+			continue
+		}
+		refs = append(refs, Ref{From: from, To: to, Line: link.Line, Snippet: strings.TrimSpace(lines[link.Line])})
+	}
+	return refs
+}
+
+// Set replaces everything a note links to
+func (g *Graph) Set(from string, refs []Ref) {
+	for _, old := range g.out[from] {
+		g.in[old.To] = slices.DeleteFunc(g.in[old.To], func(r Ref) bool { return r.From == from })
+	}
+	g.out[from] = refs
+	for _, ref := range refs {
+		g.in[ref.To] = append(g.in[ref.To], ref)
+	}
+}
+
+// Backlinks are the links into a note from other notes, by note and line
+func (g *Graph) Backlinks(to string) []Ref {
+	if g == nil {
+		return nil
+	}
+	refs := make([]Ref, 0, len(g.in[to]))
+	for _, ref := range g.in[to] {
+		if ref.From != to {
+			refs = append(refs, ref)
+		}
+	}
+	slices.SortFunc(refs, func(a, b Ref) int {
+		if c := strings.Compare(a.From, b.From); c != 0 {
+			return c
+		}
+		return a.Line - b.Line
+	})
+	return refs
+}
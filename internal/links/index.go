@@ -0,0 +1,171 @@
+package links
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mend/internal/session"
+)
+
+// Note is what the index needs to know about a note
+type Note struct {
+	Path    string
+	Aliases []string
+}
+
+// Index resolves link targets to note paths. it's built from a snapshot of the
+// tree and thrown away when the tree changes, lookups are case insensitive.
+type Index struct {
+	byAbs   map[string]string   // absolute path without .md
+	byRel   map[string]string   // path from a root without .md, rootname/ first with several roots
+	byName  map[string][]string // file name without .md
+	byAlias map[string][]string
+	rels    map[string]string // note path to its key in byRel, with the original case
+	targets []string          // what completion offers, each resolves to exactly one note
+}
+
+func NewIndex(roots []string, notes []Note) *Index {
+	ix := &Index{
+		byAbs:   make(map[string]string),
+		byRel:   make(map[string]string),
+		byName:  make(map[string][]string),
+		byAlias: make(map[string][]string),
+		rels:    make(map[string]string, len(notes)),
+	}
+	names := session.RootNames(roots)
+	for _, n := range notes {
+		ix.byAbs[strings.ToLower(strings.TrimSuffix(n.Path, ".md"))] = n.Path
+		for i, root := range roots {
+			rel, err := filepath.Rel(root, n.Path)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			rel = filepath.ToSlash(strings.TrimSuffix(rel, ".md"))
+			if len(roots) > 1 {
+				rel = names[i] + "/" + rel
+			}
+			ix.rels[n.Path] = rel
+			ix.byRel[strings.ToLower(rel)] = n.Path
+			break
+		}
+		name := strings.ToLower(strings.TrimSuffix(filepath.Base(n.Path), ".md"))
+		ix.byName[name] = append(ix.byName[name], n.Path)
+		for _, alias := range n.Aliases {
+			key := normalize(alias)
+			ix.byAlias[key] = append(ix.byAlias[key], n.Path)
+		}
+	}
+
+	for _, n := range notes {
+		name := strings.TrimSuffix(filepath.Base(n.Path), ".md")
+		if len(ix.byName[strings.ToLower(name)]) == 1 {
+			ix.targets = append(ix.targets, name)
+		} else if rel, ok := ix.rels[n.Path]; ok {
+			ix.targets = append(ix.targets, rel) // the name alone is ambiguous
+		}
+		for _, alias := range n.Aliases {
+			if len(ix.byAlias[normalize(alias)]) == 1 {
+				ix.targets = append(ix.targets, alias)
+			}
+		}
+	}
+	slices.SortFunc(ix.targets, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	ix.targets = slices.Compact(ix.targets)
+	return ix
+}
+
+// lowercase, forward slashes and no .md, the way keys are stored
+func normalize(target string) string {
+	target = strings.TrimSpace(filepath.ToSlash(target))
+	target = strings.TrimPrefix(target, "./")
+	return strings.ToLower(strings.TrimSuffix(target, ".md"))
+}
+
+// Resolve finds the note a link target written in from points to. paths are
+// tried relative to from, then to the roots, then file names and aliases.
+// an empty target is from itself.
+func (ix *Index) Resolve(target, from string) (string, bool) {
+	if strings.TrimSpace(target) == "" {
+		return from, from != ""
+	}
+	if ix == nil {
+		return "", false
+	}
+	key := normalize(target)
+	if strings.Contains(key, "/") {
+		if from != "" {
+			abs := filepath.Join(filepath.Dir(from), filepath.FromSlash(key))
+			if path, ok := ix.byAbs[strings.ToLower(abs)]; ok {
+				return path, true
+			}
+		}
+		if path, ok := ix.byRel[strings.TrimPrefix(key, "/")]; ok {
+			return path, true
+		}
+	}
+	if path := pick(ix.byName[key], from); path != "" {
+		return path, true
+	}
+	if path := pick(ix.byAlias[key], from); path != "" {
+		return path, true
+	}
+	return "", false
+}
+
+// ResolveLink is Resolve for wiki-links, markdown links are only ever relative
+// to the note they are in
+func (ix *Index) ResolveLink(link Link, from string) (string, bool) {
+	if !link.Markdown {
+		return ix.Resolve(link.Target, from)
+	}
+	if ix == nil || from == "" || filepath.IsAbs(link.Target) {
+		return "", false
+	}
+	abs := filepath.Join(filepath.Dir(from), filepath.FromSlash(link.Target))
+	path, ok := ix.byAbs[strings.ToLower(strings.TrimSuffix(abs, ".md"))]
+	return path, ok
+}
+
+// several notes with the same name: the one next to from, else the least nested
+func pick(paths []string, from string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	best := ""
+	for _, path := range paths {
+		if from != "" && filepath.Dir(path) == filepath.Dir(from) {
+			return path
+		}
+		if best == "" || len(path) < len(best) || (len(path) == len(best) && path < best) {
+			best = path
+		}
+	}
+	return best
+}
+
+// Complete offers targets for what was typed after [[, the ones starting with
+// it first, then the ones containing it
+func (ix *Index) Complete(partial string, limit int) []string {
+	if ix == nil {
+		return nil
+	}
+	query := strings.ToLower(strings.TrimSpace(partial))
+	prefixed := make([]string, 0)
+	containing := make([]string, 0)
+	for _, target := range ix.targets {
+		lower := strings.ToLower(target)
+		if strings.HasPrefix(lower, query) {
+			prefixed = append(prefixed, target)
+		} else if strings.Contains(lower, query) {
+			containing = append(containing, target)
+		}
+	}
+	matches := append(prefixed, containing...)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
@@ -0,0 +1,137 @@
+/*
+links between notes. a wiki-link is [[target]], [[target#heading]] or
+[[target|label]], the target being a note name, a path or an alias of one.
+[[#heading]] points into the note it is written in. plain markdown links
+[label](other.md#heading) count too when they are relative paths.
+*/
+
+package links
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+type Link struct {
+	Target   string // empty for a heading in the same note
+	Heading  string
+	Label    string
+	Line     int // 0 based line the link is on
+	Start    int // byte offsets of the whole [[...]] or [...](...) in the source
+	End      int
+	Markdown bool // [label](path), Target is the unescaped path
+	// where the destination of a markdown link is, to rewrite just that
+	destStart int
+	destEnd   int
+}
+
+// Text is the link as written between the brackets
+func (l Link) Text() string {
+	text := l.Target
+	if l.Heading != "" {
+		text += "#" + l.Heading
+	}
+	return text
+}
+
+var (
+	wikiRe    = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+	partialRe = regexp.MustCompile(`\[\[([^\[\]|#\n]*)$`)
+	// [label](dest "title"), the ! of images is checked separately
+	markdownRe = regexp.MustCompile(`\[([^\[\]\n]*)\]\(([^()\s]+)(?:\s+"[^"\n]*")?\)`)
+)
+
+// Parse finds every wiki-link in a note, code blocks are skipped
+func Parse(source []byte) []Link {
+	links := make([]Link, 0)
+	eachLine(source, func(i, offset int, line string) {
+		for _, loc := range wikiRe.FindAllStringSubmatchIndex(line, -1) {
+			link := parseInner(line[loc[2]:loc[3]])
+			link.Line = i
+			link.Start = offset + loc[0]
+			link.End = offset + loc[1]
+			links = append(links, link)
+		}
+	})
+	return links
+}
+
+// ParseMarkdown finds the markdown links in a note that could point at another
+// note: no urls, no images and no anchors within the same note
+func ParseMarkdown(source []byte) []Link {
+	links := make([]Link, 0)
+	eachLine(source, func(i, offset int, line string) {
+		for _, loc := range markdownRe.FindAllStringSubmatchIndex(line, -1) {
+			if loc[0] > 0 && line[loc[0]-1] == '!' {
+				continue
+			}
+			dest := line[loc[4]:loc[5]]
+			if strings.Contains(dest, "://") || strings.HasPrefix(dest, "mailto:") || strings.HasPrefix(dest, "#") {
+				continue
+			}
+			path, heading, _ := strings.Cut(dest, "#")
+			if unescaped, err := url.PathUnescape(path); err == nil {
+				path = unescaped
+			}
+			links = append(links, Link{
+				Target:    path,
+				Heading:   heading,
+				Label:     line[loc[2]:loc[3]],
+				Line:      i,
+				Start:     offset + loc[0],
+				End:       offset + loc[1],
+				Markdown:  true,
+				destStart: offset + loc[4],
+				destEnd:   offset + loc[5],
+			})
+		}
+	})
+	return links
+}
+
+// calls fn with every line outside fenced code and its byte offset
+func eachLine(source []byte, fn func(i, offset int, line string)) {
+	offset := 0
+	inFence := false
+	for i, line := range strings.Split(string(source), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		} else if !inFence {
+			fn(i, offset, line)
+		}
+		offset += len(line) + 1
+	}
+}
+
+func parseInner(inner string) Link {
+	var link Link
+	inner, link.Label, _ = strings.Cut(inner, "|")
+	link.Target, link.Heading, _ = strings.Cut(inner, "#")
+	link.Target = strings.TrimSpace(link.Target)
+	link.Heading = strings.TrimSpace(link.Heading)
+	link.Label = strings.TrimSpace(link.Label)
+	return link
+}
+
+// At gives the link a column (in runes) of a line is on, if any
+func At(line string, col int) (Link, bool) {
+	for _, loc := range wikiRe.FindAllStringSubmatchIndex(line, -1) {
+		start := len([]rune(line[:loc[0]]))
+		end := len([]rune(line[:loc[1]]))
+		if col >= start && col <= end {
+			return parseInner(line[loc[2]:loc[3]]), true
+		}
+	}
+	return Link{}, false
+}
+
+// Partial is the target typed so far when text ends inside an unclosed [[
+func Partial(beforeCursor string) (string, bool) {
+	m := partialRe.FindStringSubmatch(beforeCursor)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
@@ -0,0 +1,169 @@
+package links
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// tests finding wiki-links with headings and labels, skipping code
+func TestParse(t *testing.T) {
+	source := []byte("see [[Go notes#Slices|slices]] and [[#Intro]]\n```\n[[not a link]]\n```\n[[a/b.md]]")
+
+	got := Parse(source)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 links, got %+v", got)
+	}
+	if got[0].Target != "Go notes" || got[0].Heading != "Slices" || got[0].Label != "slices" {
+		t.Errorf("unexpected first link %+v", got[0])
+	}
+	if string(source[got[0].Start:got[0].End]) != "[[Go notes#Slices|slices]]" {
+		t.Errorf("expected offsets to cover the link, got %q", source[got[0].Start:got[0].End])
+	}
+	if got[1].Target != "" || got[1].Heading != "Intro" {
+		t.Errorf("expected a heading in the same note, got %+v", got[1])
+	}
+	if got[2].Target != "a/b.md" || got[2].Line != 4 {
+		t.Errorf("unexpected last link %+v", got[2])
+	}
+
+	if link, ok := At("x [[one]] [[two]]", 12); !ok || link.Target != "two" {
+		t.Errorf("expected the link under the cursor, got %+v", link)
+	}
+	if _, ok := At("x [[one]] y", 11); ok {
+		t.Error("expected no link outside the brackets")
+	}
+	if partial, ok := Partial("text [[go no"); !ok || partial != "go no" {
+		t.Errorf("expected a partial target, got %q", partial)
+	}
+	if _, ok := Partial("text [[done]] more"); ok {
+		t.Error("expected no partial after a closed link")
+	}
+}
+
+// tests resolving by path, name and alias, and completion
+func TestIndex(t *testing.T) {
+	root := filepath.Join("/", "vault")
+	a := filepath.Join(root, "a.md")
+	dupTop := filepath.Join(root, "dup.md")
+	dupDeep := filepath.Join(root, "deep", "dup.md")
+	golang := filepath.Join(root, "deep", "golang.md")
+	ix := NewIndex([]string{root}, []Note{
+		{Path: a},
+		{Path: dupTop},
+		{Path: dupDeep},
+		{Path: golang, Aliases: []string{"Go Notes"}},
+	})
+
+	tests := []struct {
+		target, from, want string
+	}{
+		{"A", "", a},
+		{"a.md", "", a},
+		{"deep/dup", "", dupDeep},
+		{"./dup", dupDeep, dupDeep},
+		{"../a", golang, a},
+		{"dup", a, dupTop},
+		{"dup", golang, dupDeep}, // next to the linking note wins
+		{"go notes", "", golang},
+		{"", a, a},
+	}
+	for _, tt := range tests {
+		if got, ok := ix.Resolve(tt.target, tt.from); !ok || got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", tt.target, tt.from, got, tt.want)
+		}
+	}
+	if _, ok := ix.Resolve("missing", a); ok {
+		t.Error("expected a missing note not to resolve")
+	}
+
+	got := ix.Complete("go", 10)
+	if !slices.Equal(got, []string{"Go Notes", "golang"}) {
+		t.Errorf("Complete() = %v", got)
+	}
+	if got := ix.Complete("dup", 10); !slices.Equal(got, []string{"dup", "deep/dup"}) {
+		t.Errorf("expected ambiguous names as paths, got %v", got)
+	}
+}
+
+// tests markdown links and the backlinks built from both kinds
+func TestGraph(t *testing.T) {
+	root := filepath.Join("/", "vault")
+	a := filepath.Join(root, "a.md")
+	b := filepath.Join(root, "sub", "b.md")
+	ix := NewIndex([]string{root}, []Note{{Path: a}, {Path: b}})
+
+	md := ParseMarkdown([]byte("[b](sub/b%20x.md#h) ![img](pic.png) [web](https://x.org) [here](#top)"))
+	if len(md) != 1 || md[0].Target != "sub/b x.md" || md[0].Heading != "h" || !md[0].Markdown {
+		t.Fatalf("expected one relative note link, got %+v", md)
+	}
+
+	g := NewGraph()
+	g.Set(a, Refs(a, []byte("intro\nsee [[b]] and [again](sub/b.md)\n[[missing]]"), ix))
+	g.Set(b, Refs(b, []byte("back to [a](../a.md), [[#self]]"), ix))
+
+	back := g.Backlinks(b)
+	if len(back) != 2 || back[0].From != a || back[0].Line != 1 || back[0].Snippet != "see [[b]] and [again](sub/b.md)" {
+		t.Errorf("unexpected backlinks of b: %+v", back)
+	}
+	if back := g.Backlinks(a); len(back) != 1 || back[0].From != b {
+		t.Errorf("expected b to link to a, got %+v", back)
+	}
+
+	// links change when the note is saved
+	g.Set(a, Refs(a, []byte("nothing now"), ix))
+	if back := g.Backlinks(b); len(back) != 0 {
+		t.Errorf("expected old links to go, got %+v", back)
+	}
+}
+
+// tests rewriting links in both directions when a note moves
+func TestPlanRewrites(t *testing.T) {
+	root, err := os.MkdirTemp("", "mend_links_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	a := filepath.Join(root, "a.md")
+	oldB := filepath.Join(root, "sub", "b.md")
+	newB := filepath.Join(root, "c.md")
+	os.WriteFile(a, []byte("[[b]] [[sub/b.md#H|label]] [[./sub/b]] [[Bee]] [x](sub/b.md#H) [[a]]"), 0644)
+	os.WriteFile(newB, []byte("up [a](../a.md) and [[a]]"), 0644)
+
+	before := NewIndex([]string{root}, []Note{{Path: a}, {Path: oldB, Aliases: []string{"Bee"}}})
+	after := NewIndex([]string{root}, []Note{{Path: a}, {Path: newB, Aliases: []string{"Bee"}}})
+	edits := PlanRewrites(before, after, []Move{{From: oldB, To: newB}}, []string{a, newB})
+	if len(edits) != 2 {
+		t.Fatalf("expected both notes to change, got %+v", edits)
+	}
+
+	want := "[[c]] [[c.md#H|label]] [[./c]] [[Bee]] [x](c.md#H) [[a]]"
+	if got := string(edits[0].After); got != want {
+		t.Errorf("links to the moved note:\n got %s\nwant %s", got, want)
+	}
+	if len(edits[0].Changes) != 4 || edits[0].Changes[0] != "[[b]] -> [[c]]" {
+		t.Errorf("unexpected changes %v", edits[0].Changes)
+	}
+	if got := string(edits[1].After); got != "up [a](a.md) and [[a]]" {
+		t.Errorf("links from the moved note: got %s", got)
+	}
+
+	// a note changed after planning stops the whole rewrite
+	os.WriteFile(a, []byte("[[b]] edited"), 0644)
+	if err := Apply(edits); err == nil {
+		t.Error("expected an error for a note changed since planning")
+	}
+	if data, _ := os.ReadFile(newB); string(data) != "up [a](../a.md) and [[a]]" {
+		t.Errorf("expected no note rewritten, got %s", data)
+	}
+
+	os.WriteFile(a, edits[0].Before, 0644)
+	if err := Apply(edits); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(a); string(data) != want {
+		t.Errorf("expected the edit written, got %s", data)
+	}
+}
@@ -0,0 +1,192 @@
+package links
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mend/internal/filesystem"
+)
+
+// Move is a note that changed path
+type Move struct {
+	From string
+	To   string
+}
+
+// Edit is the rewritten content of one note
+type Edit struct {
+	Path    string
+	Before  []byte
+	After   []byte
+	Changes []string // old -> new of every link, for the preview
+}
+
+// a single link to replace, offsets in the note's content
+type replacement struct {
+	start, end int
+	text       string
+	old        string
+}
+
+// PlanRewrites works out how every note has to change so its links still point
+// at the same notes after the moves. before resolves links the way they were
+// meant, after is the index with the moves done. notes are the current paths.
+func PlanRewrites(before, after *Index, moves []Move, notes []string) []Edit {
+	movedTo := make(map[string]string, len(moves))
+	movedFrom := make(map[string]string, len(moves))
+	for _, mv := range moves {
+		movedTo[mv.From] = mv.To
+		movedFrom[mv.To] = mv.From
+	}
+
+	edits := make([]Edit, 0)
+	for _, path := range notes {
+		origin := path // where the note was when its links were written
+		if from, ok := movedFrom[path]; ok {
+			origin = from
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		reps := make([]replacement, 0)
+		for _, link := range append(Parse(data), ParseMarkdown(data)...) {
+			oldTarget, ok := before.ResolveLink(link, origin)
+			if !ok || link.Target == "" {
+				continue
+			}
+			newTarget := oldTarget
+			if to, ok := movedTo[oldTarget]; ok {
+				newTarget = to
+			}
+			if newTarget == oldTarget && origin == path {
+				continue // neither end moved
+			}
+			if rep, ok := rewriteLink(data, link, before, after, oldTarget, newTarget, path, origin != path); ok {
+				reps = append(reps, rep)
+			}
+		}
+		if len(reps) == 0 {
+			continue
+		}
+
+		// back to front so earlier offsets stay valid
+		slices.SortFunc(reps, func(a, b replacement) int { return b.start - a.start })
+		out := bytes.Clone(data)
+		changes := make([]string, 0, len(reps))
+		for _, rep := range reps {
+			out = slices.Concat(out[:rep.start], []byte(rep.text), out[rep.end:])
+			changes = append(changes, rep.old+" -> "+rep.text)
+		}
+		slices.Reverse(changes)
+		edits = append(edits, Edit{Path: path, Before: data, After: out, Changes: changes})
+	}
+	return edits
+}
+
+func rewriteLink(data []byte, link Link, before, after *Index, oldTarget, newTarget, from string, fromMoved bool) (replacement, bool) {
+	if link.Markdown {
+		dest := relativeDest(from, newTarget, strings.HasPrefix(link.Target, "./"))
+		if link.Heading != "" {
+			dest += "#" + link.Heading
+		}
+		old := string(data[link.destStart:link.destEnd])
+		if dest == old {
+			return replacement{}, false
+		}
+		return replacement{start: link.destStart, end: link.destEnd, text: dest, old: old}, true
+	}
+
+	target, ok := wikiTarget(link, before, after, oldTarget, newTarget, from, fromMoved)
+	if !ok {
+		return replacement{}, false
+	}
+	text := "[[" + target
+	if link.Heading != "" {
+		text += "#" + link.Heading
+	}
+	if link.Label != "" {
+		text += "|" + link.Label
+	}
+	text += "]]"
+	return replacement{start: link.Start, end: link.End, text: text, old: string(data[link.Start:link.End])}, true
+}
+
+// the new target of a wiki-link, written the same way it was: name, alias,
+// path relative to the note or path from the root
+func wikiTarget(link Link, before, after *Index, oldTarget, newTarget, from string, fromMoved bool) (string, bool) {
+	key := normalize(link.Target)
+	ext := ""
+	if strings.HasSuffix(strings.ToLower(link.Target), ".md") {
+		ext = ".md"
+	}
+	raw := filepath.ToSlash(link.Target)
+	relative := strings.HasPrefix(raw, "./") || strings.HasPrefix(raw, "../")
+
+	switch {
+	case relative:
+		if newTarget == oldTarget && !fromMoved {
+			return "", false
+		}
+		dest := relativeDest(from, newTarget, strings.HasPrefix(link.Target, "./"))
+		return strings.TrimSuffix(dest, ".md") + ext, true
+
+	case !strings.Contains(key, "/"):
+		if newTarget == oldTarget {
+			return "", false // names don't care where the linking note is
+		}
+		if key != strings.ToLower(strings.TrimSuffix(filepath.Base(oldTarget), ".md")) &&
+			slices.Contains(before.byAlias[key], oldTarget) {
+			return "", false // an alias, it moved along with the note
+		}
+		name := strings.TrimSuffix(filepath.Base(newTarget), ".md")
+		if resolved, ok := after.Resolve(name, from); ok && resolved == newTarget {
+			return name + ext, true
+		}
+	}
+
+	if newTarget == oldTarget {
+		return "", false
+	}
+	rel, ok := after.rels[newTarget]
+	if !ok {
+		return "", false
+	}
+	return rel + ext, true
+}
+
+// path from the folder of from to target with forward slashes, spaces escaped
+// so it stays a valid markdown link destination
+func relativeDest(from, target string, dotSlash bool) string {
+	rel, err := filepath.Rel(filepath.Dir(from), target)
+	if err != nil {
+		rel = target
+	}
+	rel = filepath.ToSlash(rel)
+	if dotSlash && !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return strings.ReplaceAll(rel, " ", "%20")
+}
+
+// Apply writes every edit or none of them. a note that changed since the plan
+// was made stops the whole rewrite.
+func Apply(edits []Edit) error {
+	writes := make([]filesystem.FileWrite, 0, len(edits))
+	for _, edit := range edits {
+		current, err := os.ReadFile(edit.Path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, edit.Before) {
+			return errors.New(filepath.Base(edit.Path) + " changed in the meantime, links were not updated")
+		}
+		writes = append(writes, filesystem.FileWrite{Path: edit.Path, Data: edit.After})
+	}
+	return filesystem.WriteAll(writes)
+}
@@ -0,0 +1,80 @@
+/*
+the spaced repetition side of mend. every section of a note is a card.
+there is no scheduling state stored anywhere yet, so nothing here knows which
+cards are due, only how many a note has. notes with srs: false in their
+frontmatter have no cards.
+*/
+
+package review
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"mend/internal/frontmatter"
+	"mend/internal/sections"
+)
+
+type cacheEntry struct {
+	modTime time.Time
+	count   int
+	skip    bool
+}
+
+// parsing every note is not cheap, counts are cached until the file changes
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]cacheEntry)
+)
+
+// read gives what review needs to know about a note, false for anything that isn't one
+func read(path string) (cacheEntry, bool) {
+	if !strings.HasSuffix(path, ".md") {
+		return cacheEntry{}, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	cacheMu.Lock()
+	entry, ok := cache[path]
+	cacheMu.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) {
+		return entry, true
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	entry = cacheEntry{modTime: info.ModTime(), skip: frontmatter.Parse(data).SkipReview}
+	if !entry.skip {
+		entry.count = len(sections.Parse(data))
+	}
+
+	cacheMu.Lock()
+	cache[path] = entry
+	cacheMu.Unlock()
+	return entry, true
+}
+
+// CardCount is the number of cards in a note, 0 for anything that isn't a note
+// or opted out of reviews
+func CardCount(path string) int {
+	entry, _ := read(path)
+	return entry.count
+}
+
+// Reviewable drops the notes that opted out of reviews, order is kept
+func Reviewable(paths []string) []string {
+	kept := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if entry, ok := read(path); !ok || !entry.skip {
+			kept = append(kept, path)
+		}
+	}
+	return kept
+}
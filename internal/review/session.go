@@ -0,0 +1,36 @@
+package review
+
+// Session walks through a fixed list of notes one after the other
+type Session struct {
+	paths []string
+	pos   int
+}
+
+func NewSession(paths []string) *Session {
+	return &Session{paths: paths}
+}
+
+// Current is the note under review, empty once the session is done
+func (s *Session) Current() string {
+	if s.Done() {
+		return ""
+	}
+	return s.paths[s.pos]
+}
+
+// Next moves on to the next note and returns it, empty when there are no more
+func (s *Session) Next() string {
+	if !s.Done() {
+		s.pos++
+	}
+	return s.Current()
+}
+
+func (s *Session) Done() bool {
+	return s.pos >= len(s.paths)
+}
+
+// Progress is the 1 based position and the total, for display
+func (s *Session) Progress() (int, int) {
+	return min(s.pos+1, len(s.paths)), len(s.paths)
+}
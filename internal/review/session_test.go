@@ -0,0 +1,25 @@
+package review
+
+import "testing"
+
+// tests stepping through a review session
+func TestSession(t *testing.T) {
+	s := NewSession([]string{"a.md", "b.md"})
+
+	if s.Current() != "a.md" {
+		t.Errorf("expected a.md, got %q", s.Current())
+	}
+	if pos, total := s.Progress(); pos != 1 || total != 2 {
+		t.Errorf("expected progress 1/2, got %d/%d", pos, total)
+	}
+	if s.Next() != "b.md" {
+		t.Error("expected next to be b.md")
+	}
+	if s.Next() != "" || !s.Done() {
+		t.Error("expected session to be done")
+	}
+	// stays done
+	if s.Next() != "" {
+		t.Error("expected no more notes")
+	}
+}
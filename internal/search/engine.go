@@ -9,6 +9,12 @@ import (
 	"slices"
 	"strings"
 
+	"mend/internal/filesystem"
+	"mend/internal/frontmatter"
+	"mend/internal/ignore"
+	"mend/internal/links"
+	"mend/internal/session"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -17,23 +23,29 @@ const defaultContextLen = 40
 // SearchResult represents a single search match
 type SearchResult struct {
 	Path         string
-	RelativePath string
+	RelativePath string // relative to the root the file is in
+	RootName     string // only set when several roots are indexed
 	FileName     string
 	Snippet      string
 	Score        int
 	IsFolder     bool
 }
 
+// only touched on the ui goroutine, indexing passes build their results on
+// the side and hand them back in an IndexedMsg
 type SearchEngine struct {
 	files      []fileEntry
 	isIndexing bool
+	generation int // bumped for every pass, only the latest one is applied
 }
 
 // internal struct used while indexing
 type fileEntry struct {
 	path         string
 	relativePath string
+	rootName     string
 	fileName     string
+	names        []string // frontmatter title and aliases, found like the file name
 	content      string
 	isFolder     bool
 }
@@ -48,73 +60,161 @@ func (e *SearchEngine) IsIndexing() bool {
 	return e.isIndexing
 }
 
-// tea cmd for indexing
-func StartIndexing(engine *SearchEngine, rootPath string) tea.Cmd {
-	return func() tea.Msg {
-		engine.isIndexing = true
-		engine.files = make([]fileEntry, 0)
+// IndexedMsg is sent when an indexing pass is done, with the links it found on
+// the way. it has to go through Apply before anything in it is used
+type IndexedMsg struct {
+	Links      *links.Index
+	Graph      *links.Graph
+	files      []fileEntry
+	generation int
+}
 
-		// file walker
-		filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil // skip errors
-			}
+// Apply swaps in what an indexing pass found. false for a pass a newer one was
+// started after, its files and links are stale and should be dropped
+func (e *SearchEngine) Apply(msg IndexedMsg) bool {
+	if msg.generation != e.generation {
+		return false
+	}
+	e.files = msg.files
+	e.isIndexing = false
+	return true
+}
 
-			// Skip hidden files/folders
-			if strings.HasPrefix(info.Name(), ".") {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
+// a note as read while indexing, kept until its links are resolved
+type noteSource struct {
+	path    string
+	aliases []string
+	data    []byte
+}
 
-			// Skip root path itself
-			if path == rootPath {
-				return nil
+// tea cmd for indexing every root, matcher is the same one the tree uses (nil
+// for none). passes can overlap, whichever started last wins in Apply
+func StartIndexing(engine *SearchEngine, rootPaths []string, matcher *ignore.Matcher) tea.Cmd {
+	engine.isIndexing = true
+	engine.generation++
+	generation := engine.generation
+	return func() tea.Msg {
+		// built on the side so a re-index doesn't empty the results while it runs
+		files := make([]fileEntry, 0)
+		notes := make([]noteSource, 0)
+		names := session.RootNames(rootPaths)
+		for i, rootPath := range rootPaths {
+			rootName := ""
+			if len(rootPaths) > 1 {
+				rootName = names[i]
 			}
+			files = indexRoot(files, &notes, rootPath, rootName, matcher)
+		}
 
-			// Compute relative path from root
-			relPath, _ := filepath.Rel(rootPath, path)
-
-			if info.IsDir() {
-				// Index folder
-				engine.files = append(engine.files, fileEntry{
-					path:         path,
-					relativePath: relPath,
-					fileName:     info.Name(),
-					content:      "",
-					isFolder:     true,
-				})
-				return nil
-			}
+		msg := linkNotes(rootPaths, notes)
+		msg.files = files
+		msg.generation = generation
+		return msg
+	}
+}
 
-			// file handling
-			// Only index .md files
-			if !strings.HasSuffix(info.Name(), ".md") {
-				return nil
-			}
+// links can only be resolved once every note is known
+func linkNotes(rootPaths []string, notes []noteSource) IndexedMsg {
+	known := make([]links.Note, 0, len(notes))
+	for _, n := range notes {
+		known = append(known, links.Note{Path: n.path, Aliases: n.aliases})
+	}
+	index := links.NewIndex(rootPaths, known)
+	graph := links.NewGraph()
+	for _, n := range notes {
+		graph.Set(n.path, links.Refs(n.path, n.data, index))
+	}
+	return IndexedMsg{Links: index, Graph: graph}
+}
 
-			content, err := os.ReadFile(path)
-			if err != nil {
-				return nil
-			}
+func indexRoot(files []fileEntry, notes *[]noteSource, rootPath, rootName string, matcher *ignore.Matcher) []fileEntry {
+	walk(rootPath, filesystem.NewLoopGuard(), func(entry filesystem.Entry) bool {
+		path := entry.Path
 
-			fileName := strings.TrimSuffix(info.Name(), ".md")
-			relPathDisplay := strings.TrimSuffix(relPath, ".md")
+		// Skip hidden files/folders
+		if strings.HasPrefix(entry.Name, ".") {
+			return false
+		}
+
+		if matcher.Ignored(path, entry.IsDir) {
+			return false
+		}
 
-			engine.files = append(engine.files, fileEntry{
+		// Compute relative path from root
+		relPath, _ := filepath.Rel(rootPath, path)
+
+		if entry.IsDir {
+			// Index folder
+			files = append(files, fileEntry{
 				path:         path,
-				relativePath: relPathDisplay,
-				fileName:     fileName,
-				content:      strings.ToLower(string(content)),
+				relativePath: relPath,
+				rootName:     rootName,
+				fileName:     entry.Name,
+				content:      "",
+				isFolder:     true,
+			})
+			return true
+		}
+
+		// file handling
+		// attachments are only findable by name, their content is not text worth searching
+		if filesystem.KindOf(path) != filesystem.KindNote {
+			files = append(files, fileEntry{
+				path:         path,
+				relativePath: relPath,
+				rootName:     rootName,
+				fileName:     entry.Name,
+				content:      "",
 				isFolder:     false,
 			})
+			return false
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return false
+		}
+
+		fileName := strings.TrimSuffix(entry.Name, ".md")
+		relPathDisplay := strings.TrimSuffix(relPath, ".md")
+		meta := frontmatter.Parse(content)
+		*notes = append(*notes, noteSource{path: path, aliases: meta.Aliases, data: content})
+		names := meta.Aliases
+		if meta.Title != "" {
+			names = append([]string{meta.Title}, names...)
+		}
 
-			return nil
+		files = append(files, fileEntry{
+			path:         path,
+			relativePath: relPathDisplay,
+			rootName:     rootName,
+			fileName:     fileName,
+			names:        names,
+			content:      strings.ToLower(string(content)),
+			isFolder:     false,
 		})
+		return false
+	})
+	return files
+}
 
-		engine.isIndexing = false
-		return nil
+// walk goes through everything below dir the same way the tree does: folder
+// links are followed, unless they lead back up into the walk. fn says whether
+// to go into a folder.
+func walk(dir string, guard *filesystem.LoopGuard, fn func(entry filesystem.Entry) bool) {
+	leave, ok := guard.Enter(dir)
+	if !ok {
+		return
+	}
+	defer leave()
+	entries, err := filesystem.ReadDir(dir)
+	if err != nil {
+		return // skip errors
+	}
+	for _, entry := range entries {
+		if fn(entry) && entry.IsDir {
+			walk(entry.Path, guard, fn)
+		}
 	}
 }
 
@@ -144,6 +244,7 @@ func (e *SearchEngine) Search(query string) []SearchResult {
 			results = append(results, SearchResult{
 				Path:         file.path,
 				RelativePath: file.relativePath,
+				RootName:     file.rootName,
 				FileName:     file.fileName,
 				Snippet:      "",
 				Score:        score,
@@ -152,6 +253,26 @@ func (e *SearchEngine) Search(query string) []SearchResult {
 			continue // else double-count
 		}
 
+		// a title or alias counts as a name, shown as the snippet since it isn't the file name
+		if name, pos := matchName(file.names, queryLower); pos >= 0 {
+			score := 80
+			if strings.ToLower(name) == queryLower {
+				score += 100
+			} else if isWordMatch(queryLower, strings.ToLower(name), pos) {
+				score += 50
+			}
+			results = append(results, SearchResult{
+				Path:         file.path,
+				RelativePath: file.relativePath,
+				RootName:     file.rootName,
+				FileName:     file.fileName,
+				Snippet:      name,
+				Score:        score,
+				IsFolder:     false,
+			})
+			continue
+		}
+
 		// Check content matches on files
 		if !file.isFolder {
 			matchPos := strings.Index(contentLower, queryLower)
@@ -164,6 +285,7 @@ func (e *SearchEngine) Search(query string) []SearchResult {
 				results = append(results, SearchResult{
 					Path:         file.path,
 					RelativePath: file.relativePath,
+					RootName:     file.rootName,
 					FileName:     file.fileName,
 					Snippet:      snippet,
 					Score:        score,
@@ -179,6 +301,16 @@ func (e *SearchEngine) Search(query string) []SearchResult {
 	return results
 }
 
+// matchName finds the first name containing the query, pos is -1 if none do
+func matchName(names []string, queryLower string) (string, int) {
+	for _, name := range names {
+		if pos := strings.Index(strings.ToLower(name), queryLower); pos >= 0 {
+			return name, pos
+		}
+	}
+	return "", -1
+}
+
 // isWordMatch checks if query appears as a complete word in target
 func isWordMatch(query, target string, indexInTarget int) bool {
 	// Go language note: these ^ strings are not getting copied again like C++, they are just references
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"mend/internal/ignore"
 )
 
 func TestIsWordMatch(t *testing.T) {
@@ -126,3 +128,127 @@ func TestExtractSnippet(t *testing.T) {
 		t.Error("expected non-empty snippet")
 	}
 }
+
+func TestStartIndexingIgnore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "search_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.Mkdir(filepath.Join(tmpDir, "vendor"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "vendor", "lib.md"), []byte("needle"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "notes.md"), []byte("needle"), 0644)
+
+	engine := NewSearchEngine()
+	engine.Apply(StartIndexing(engine, []string{tmpDir}, ignore.FromPatterns(tmpDir, "vendor/"))().(IndexedMsg))
+
+	results := engine.Search("needle")
+	if len(results) != 1 || results[0].FileName != "notes" {
+		t.Errorf("expected only notes.md to be indexed, got %+v", results)
+	}
+}
+
+func TestStartIndexingRoots(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "search_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	personal := filepath.Join(tmpDir, "personal")
+	shared := filepath.Join(tmpDir, "shared")
+	os.MkdirAll(filepath.Join(personal, "ideas"), 0755)
+	os.Mkdir(shared, 0755)
+	os.WriteFile(filepath.Join(personal, "ideas", "a.md"), []byte("needle"), 0644)
+	os.WriteFile(filepath.Join(shared, "b.md"), []byte("needle"), 0644)
+
+	engine := NewSearchEngine()
+	engine.Apply(StartIndexing(engine, []string{personal, shared}, nil)().(IndexedMsg))
+
+	results := engine.Search("needle")
+	if len(results) != 2 {
+		t.Fatalf("expected a result from each root, got %+v", results)
+	}
+	byRoot := map[string]string{}
+	for _, r := range results {
+		byRoot[r.RootName] = r.RelativePath
+	}
+	if byRoot["personal"] != filepath.Join("ideas", "a") || byRoot["shared"] != "b" {
+		t.Errorf("expected paths relative to their own root, got %v", byRoot)
+	}
+}
+
+func TestStartIndexingSymlinks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "search_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	outside, err := os.MkdirTemp("", "search_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outside)
+	os.WriteFile(filepath.Join(outside, "linked.md"), []byte("needle"), 0644)
+
+	if err := os.Symlink(outside, filepath.Join(tmpDir, "link")); err != nil {
+		t.Skip("symlinks not supported")
+	}
+	os.Symlink(tmpDir, filepath.Join(outside, "back"))
+
+	engine := NewSearchEngine()
+	engine.Apply(StartIndexing(engine, []string{tmpDir}, nil)().(IndexedMsg))
+
+	results := engine.Search("needle")
+	if len(results) != 1 || results[0].Path != filepath.Join(tmpDir, "link", "linked.md") {
+		t.Errorf("expected the linked note once through the link, got %+v", results)
+	}
+}
+
+func TestSearchFrontmatterNames(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "search_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "2024-01-03.md"),
+		[]byte("---\ntitle: Standup notes\naliases: [daily sync]\n---\nbody"), 0644)
+
+	engine := NewSearchEngine()
+	engine.Apply(StartIndexing(engine, []string{tmpDir}, nil)().(IndexedMsg))
+
+	for _, query := range []string{"standup", "daily sync"} {
+		results := engine.Search(query)
+		if len(results) != 1 || results[0].FileName != "2024-01-03" || results[0].Score < 100 {
+			t.Errorf("expected %q to find the note by name, got %+v", query, results)
+		}
+	}
+}
+
+// tests that a pass overtaken by a newer one is dropped, whatever order they finish in
+func TestIndexingGenerations(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "search_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	os.WriteFile(filepath.Join(tmpDir, "old.md"), []byte("needle"), 0644)
+
+	engine := NewSearchEngine()
+	older := StartIndexing(engine, []string{tmpDir}, nil)
+	os.WriteFile(filepath.Join(tmpDir, "new.md"), []byte("needle"), 0644)
+	newer := StartIndexing(engine, []string{tmpDir}, nil)
+
+	if !engine.Apply(newer().(IndexedMsg)) {
+		t.Fatal("expected the latest pass to be applied")
+	}
+	if engine.Apply(older().(IndexedMsg)) {
+		t.Error("expected the older pass to be dropped")
+	}
+	if results := engine.Search("needle"); len(results) != 2 {
+		t.Errorf("expected the newer index kept, got %+v", results)
+	}
+}
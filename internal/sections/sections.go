@@ -0,0 +1,138 @@
+/*
+a note is a stack of sections split at its headings, each one a card when
+reviewing. parsed here without any ui so the note view, review and link
+following all agree on where a section starts and ends.
+*/
+package sections
+
+import (
+	"regexp"
+	"strings"
+
+	"mend/internal/frontmatter"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+type Section struct {
+	Title   string
+	Content string
+	Hints   []string
+	// byte range of the heading and body in the whole file, up to the next section
+	Start int
+	End   int
+}
+
+// Parse splits a note into its cards, frontmatter is not part of any.
+// its title stands in for a missing first heading
+func Parse(source []byte) []Section {
+	title := "no title"
+	offset := 0 // where the body starts in the file
+	if fm, body, ok := frontmatter.Split(source); ok {
+		if meta := frontmatter.ParseBlock(fm); meta.Title != "" {
+			title = "# " + meta.Title
+		}
+		offset = len(source) - len(body)
+		source = body
+	}
+
+	md := goldmark.New()
+	reader := text.NewReader(source)
+	doc := md.Parser().Parse(reader)
+
+	sections := make([]Section, 0)
+	lastPos := 0
+	sectionStart := 0
+
+	for child := doc.FirstChild(); child != nil; child = child.NextSibling() {
+		if child.Kind() == ast.KindHeading {
+			if child.Lines().Len() <= 0 {
+				continue
+			}
+
+			// the heading text starts after the #s, the section at the start of its line
+			headingStart := child.Lines().At(0).Start
+			for headingStart > 0 && source[headingStart-1] != '\n' {
+				headingStart--
+			}
+			headingEnd := child.Lines().At(child.Lines().Len() - 1).Stop
+
+			contentEnd := headingStart
+			if lastPos < contentEnd {
+				// you have a heading and a content to accumulte over
+				contentsRaw := source[lastPos:contentEnd]
+				contents := strings.TrimSpace(string(contentsRaw))
+				hints := ExtractHints(contents)
+				sections = append(sections, Section{
+					Title:   title,
+					Content: contents,
+					Hints:   hints,
+					Start:   offset + sectionStart,
+					End:     offset + headingStart,
+				})
+				lastPos = headingEnd
+			}
+			// for the next heading
+			title = strings.TrimSpace(string(source[headingStart:headingEnd]))
+			lastPos = headingEnd
+			sectionStart = headingStart
+		}
+	}
+	// last section
+	if lastPos < len(source) {
+		contentsRaw := source[lastPos:]
+		contents := strings.TrimSpace(string(contentsRaw))
+		hints := ExtractHints(contents)
+		sections = append(sections, Section{
+			Title:   title,
+			Content: contents,
+			Hints:   hints,
+			Start:   offset + sectionStart,
+			End:     offset + len(source),
+		})
+	}
+
+	return sections
+}
+
+func ExtractHints(content string) []string {
+	re := regexp.MustCompile(`(?s)\*\*(.*?)\*\*|__(.*?)__`)
+	matches := re.FindAllStringSubmatch(content, -1)
+	hints := make([]string, 0)
+	for _, match := range matches {
+		if len(match) > 1 && match[1] != "" {
+			hints = append(hints, match[1])
+		} else if len(match) > 2 && match[2] != "" {
+			hints = append(hints, match[2])
+		}
+	}
+	return hints
+}
+
+// Index finds the section under a heading, ignoring the #s and case. 0 when there is none
+func Index(sections []Section, heading string) int {
+	want := strings.ToLower(strings.TrimSpace(heading))
+	for i, section := range sections {
+		title := strings.TrimSpace(strings.TrimLeft(section.Title, "#"))
+		if strings.ToLower(title) == want {
+			return i
+		}
+	}
+	return 0
+}
+
+// Containing finds the section a line of the note is in, 0 when it can't be found
+func Containing(sections []Section, line string) int {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return 0
+	}
+	for i, section := range sections {
+		if strings.Contains(section.Title, line) || strings.Contains(section.Content, line) {
+			return i
+		}
+	}
+	return 0
+}
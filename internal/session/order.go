@@ -0,0 +1,41 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const orderFileName = "order.json"
+
+// Order is the manual sort order of the tree, folder path relative to the root
+// ("." for the root itself) to the names of its children in order.
+// Names missing from a list simply sort after the listed ones.
+type Order map[string][]string
+
+func LoadOrder(root string) (Order, error) {
+	data, err := os.ReadFile(filepath.Join(Dir(root), orderFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return make(Order), nil
+	}
+	if err != nil {
+		return make(Order), err
+	}
+	order := make(Order)
+	if err := json.Unmarshal(data, &order); err != nil {
+		return make(Order), err
+	}
+	return order, nil
+}
+
+func SaveOrder(root string, order Order) error {
+	if err := os.MkdirAll(Dir(root), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(order, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(Dir(root), orderFileName), data, 0644)
+}
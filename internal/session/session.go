@@ -0,0 +1,174 @@
+/*
+per root session state, i.e. how the ui looked when mend was last closed on a root
+(or on a set of roots opened together).
+it lives in a dot folder inside the root so it travels with the notes and the tree
+and indexer skip it like any other dot folder
+*/
+
+package session
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mend/internal/history"
+)
+
+// DirName is the dot folder in a root where mend keeps its own files
+const DirName = ".mend"
+
+const stateFileName = "session.json"
+
+// all paths are relative to the root so that moving the folder around keeps them valid
+type State struct {
+	ExpandedPaths []string `json:"expanded_paths"`
+	SelectedPath  string   `json:"selected_path"`
+	OpenNote      string   `json:"open_note"`
+	SectionIndex  int      `json:"section_index"`
+	SidebarWidth  int      `json:"sidebar_width"`
+	ShowSidebar   bool     `json:"show_sidebar"`
+	ShowStatusBar bool     `json:"show_status_bar"`
+	SortMode      string   `json:"sort_mode"`
+	Pins          []string `json:"pins"`
+	// history of opened notes, paths stored relative like everything else
+	Recent  []history.Entry `json:"recent"`
+	Back    []history.Entry `json:"back"`
+	Forward []history.Entry `json:"forward"`
+}
+
+// Dir gives the dot folder for a root, it is not guaranteed to exist
+func Dir(root string) string {
+	return filepath.Join(root, DirName)
+}
+
+// Load reads the saved state for a root, nil without an error if there is none yet
+func Load(root string) (*State, error) {
+	return load(filepath.Join(Dir(root), stateFileName))
+}
+
+// LoadWorkspace is Load for a set of roots opened together
+func LoadWorkspace(roots []string) (*State, error) {
+	return load(workspaceFile(roots))
+}
+
+func load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func Save(root string, state *State) error {
+	return save(filepath.Join(Dir(root), stateFileName), state)
+}
+
+func SaveWorkspace(roots []string, state *State) error {
+	return save(workspaceFile(roots), state)
+}
+
+func save(path string, state *State) error {
+	if state == nil {
+		return errors.New("state cannot be nil")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// a single root keeps the usual file, a set of roots gets its own file in the
+// first root so opening one of them alone doesn't clobber it
+func workspaceFile(roots []string) string {
+	if len(roots) == 1 {
+		return filepath.Join(Dir(roots[0]), stateFileName)
+	}
+	sum := sha1.Sum([]byte(strings.Join(roots, "\x00")))
+	return filepath.Join(Dir(roots[0]), "workspace-"+hex.EncodeToString(sum[:4])+".json")
+}
+
+// Rel and Abs convert between what is stored and what the app uses,
+// anything that can't be made relative is stored as is
+func Rel(root, path string) string {
+	if path == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+func Abs(root, rel string) string {
+	if rel == "" || filepath.IsAbs(rel) {
+		return rel
+	}
+	return filepath.Join(root, rel)
+}
+
+// RootNames tells roots opened together apart in paths shown to the user. a
+// root is named after its folder, roots with the same folder name take as
+// many parent folders as it needs to differ, e.g. "work/notes" and
+// "home/notes". the same root given twice is numbered.
+func RootNames(roots []string) []string {
+	parts := make([][]string, len(roots))
+	depth := make([]int, len(roots))
+	for i, root := range roots {
+		for _, part := range strings.Split(filepath.ToSlash(filepath.Clean(root)), "/") {
+			if part != "" {
+				parts[i] = append(parts[i], part)
+			}
+		}
+		depth[i] = 1
+	}
+	name := func(i int) string {
+		return strings.Join(parts[i][max(0, len(parts[i])-depth[i]):], "/")
+	}
+	for grew := true; grew; {
+		grew = false
+		same := make(map[string][]int)
+		for i := range roots {
+			same[name(i)] = append(same[name(i)], i)
+		}
+		for _, clash := range same {
+			if len(clash) < 2 {
+				continue
+			}
+			for _, i := range clash {
+				if depth[i] < len(parts[i]) {
+					depth[i]++
+					grew = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, len(roots))
+	seen := make(map[string]int)
+	for i := range roots {
+		names[i] = name(i)
+		seen[names[i]]++
+		if n := seen[names[i]]; n > 1 {
+			names[i] = fmt.Sprintf("%s (%d)", names[i], n)
+		}
+	}
+	return names
+}
@@ -0,0 +1,107 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// tests that a saved state comes back the same
+func TestSaveLoad(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_session_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// nothing saved yet
+	state, err := Load(tmpDir)
+	if err != nil || state != nil {
+		t.Fatalf("expected nil state and no error, got %v, %v", state, err)
+	}
+
+	want := &State{
+		ExpandedPaths: []string{"a", filepath.Join("a", "b")},
+		SelectedPath:  filepath.Join("a", "note.md"),
+		OpenNote:      filepath.Join("a", "note.md"),
+		SectionIndex:  3,
+		SidebarWidth:  24,
+		ShowSidebar:   true,
+	}
+	if err := Save(tmpDir, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+// tests converting between stored and absolute paths
+func TestRelAbs(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "notes")
+	path := filepath.Join(root, "folder", "note.md")
+
+	rel := Rel(root, path)
+	if rel != filepath.Join("folder", "note.md") {
+		t.Errorf("Rel() = %s", rel)
+	}
+	if Abs(root, rel) != path {
+		t.Errorf("Abs() = %s, want %s", Abs(root, rel), path)
+	}
+	if Rel(root, "") != "" || Abs(root, "") != "" {
+		t.Error("empty paths should stay empty")
+	}
+}
+
+// tests telling apart roots with the same folder name
+func TestRootNames(t *testing.T) {
+	roots := []string{
+		filepath.Join(string(filepath.Separator), "work", "notes"),
+		filepath.Join(string(filepath.Separator), "home", "notes"),
+		filepath.Join(string(filepath.Separator), "shared"),
+		filepath.Join(string(filepath.Separator), "shared"),
+	}
+	got := RootNames(roots)
+	want := []string{"work/notes", "home/notes", "shared", "shared (2)"}
+	if !slices.Equal(got, want) {
+		t.Errorf("RootNames() = %v, want %v", got, want)
+	}
+}
+
+// tests that a set of roots doesn't share its state with its first root
+func TestWorkspaceState(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_session_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	personal := filepath.Join(tmpDir, "personal")
+	shared := filepath.Join(tmpDir, "shared")
+	roots := []string{personal, shared}
+
+	if err := Save(personal, &State{SelectedPath: "alone.md"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveWorkspace(roots, &State{SelectedPath: "together.md"}); err != nil {
+		t.Fatal(err)
+	}
+
+	alone, _ := Load(personal)
+	together, _ := LoadWorkspace(roots)
+	if alone == nil || alone.SelectedPath != "alone.md" {
+		t.Errorf("expected the single root state to be untouched, got %+v", alone)
+	}
+	if together == nil || together.SelectedPath != "together.md" {
+		t.Errorf("expected the workspace state back, got %+v", together)
+	}
+	if single, _ := LoadWorkspace([]string{personal}); single == nil || single.SelectedPath != "alone.md" {
+		t.Error("expected a single root workspace to use the root's own state")
+	}
+}
@@ -0,0 +1,170 @@
+/*
+tags of a note: the tags: list in its frontmatter and inline #tags in the
+body. reading and adding them has nothing to do with the ui, the tree's tags
+view and bulk tagging go through here.
+*/
+package tags
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"mend/internal/filesystem"
+	"mend/internal/frontmatter"
+)
+
+// inline tags: #tag or #nested/tag after whitespace, so headings and
+// anchors in links don't count
+var inlineTagRe = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_][\p{L}\p{N}_/-]*)`)
+
+// Add gives a note a tag unless it already has it. a note with a tags: list in
+// its frontmatter gets it there, any other note an inline #tag at the end
+func Add(path, tag string) error {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	if tag == "" || strings.ContainsAny(tag, " \t\n") {
+		return errors.New("tag cannot be empty or contain spaces")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(Extract(data), frontmatter.NormalizeTag(tag)) {
+		return nil
+	}
+
+	content, ok := addToFrontmatter(string(data), tag)
+	if !ok {
+		content = string(data)
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += "\n#" + tag + "\n"
+	}
+	return filesystem.WriteFileAtomic(path, []byte(content))
+}
+
+// addToFrontmatter adds tag to the tags: key of the frontmatter, written the
+// way the list already is: [a, b], a, b or one - item per line. everything else
+// in the block stays byte for byte. false when there is no tags: key
+func addToFrontmatter(content, tag string) (string, bool) {
+	fm, _, ok := frontmatter.Split([]byte(content))
+	if !ok {
+		return "", false
+	}
+	start := strings.Index(content, "\n") + 1
+	lines := strings.SplitAfter(string(fm), "\n")
+	for i, line := range lines {
+		text := strings.TrimRight(line, "\r\n")
+		eol := line[len(text):]
+		value, found := strings.CutPrefix(text, "tags:")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			inner := strings.TrimSpace(value[1 : len(value)-1])
+			if inner != "" {
+				inner += ", "
+			}
+			lines[i] = "tags: [" + inner + tag + "]" + eol
+		case value != "":
+			lines[i] = text + ", " + tag + eol
+		default:
+			// block list, the new item goes after the last one and looks like it
+			last := i
+			for last+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[last+1]), "- ") {
+				last++
+			}
+			if last == i {
+				lines[i] = "tags: [" + tag + "]" + eol
+				break
+			}
+			item := strings.TrimRight(lines[last], "\r\n") // every line of the block ends in one
+			indent := item[:strings.Index(item, "-")]
+			lines = slices.Insert(lines, last+1, indent+"- "+tag+lines[last][len(item):])
+		}
+		return content[:start] + strings.Join(lines, "") + content[start+len(fm):], true
+	}
+	return "", false
+}
+
+// Extract collects the tags of a note from its frontmatter tags: and inline
+// #tags outside code blocks, each tag once in the order first seen
+func Extract(source []byte) []string {
+	tags := make([]string, 0)
+	add := func(tag string) {
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	fm, body, ok := frontmatter.Split(source)
+	if ok {
+		for _, tag := range frontmatter.ParseBlock(fm).Tags {
+			add(tag)
+		}
+	}
+
+	inFence := false
+	for _, line := range strings.Split(string(body), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		for _, m := range inlineTagRe.FindAllStringSubmatch(line, -1) {
+			add(frontmatter.NormalizeTag(m[1]))
+		}
+	}
+	return tags
+}
+
+type cacheEntry struct {
+	modTime time.Time
+	tags    []string
+}
+
+// reading every note for its tags is not cheap, cached until the file changes
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]cacheEntry)
+)
+
+// Read gives the tags of the note at path, nil for anything that isn't one
+func Read(path string) []string {
+	if !strings.HasSuffix(path, ".md") {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	cacheMu.Lock()
+	entry, ok := cache[path]
+	cacheMu.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) {
+		return entry.tags
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	entry = cacheEntry{modTime: info.ModTime(), tags: Extract(data)}
+
+	cacheMu.Lock()
+	cache[path] = entry
+	cacheMu.Unlock()
+	return entry.tags
+}
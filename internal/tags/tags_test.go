@@ -0,0 +1,84 @@
+package tags
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tests appending inline tags without duplicates
+func TestAdd(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_tags_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "note.md")
+	os.WriteFile(path, []byte("# Title\nbody"), 0600)
+
+	if err := Add(path, "go"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Add(path, "#go"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Count(string(data), "#go") != 1 {
+		t.Errorf("expected exactly one tag, got %q", string(data))
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode to be kept, got %v", info.Mode().Perm())
+	}
+
+	if err := Add(path, "two words"); err == nil {
+		t.Error("expected error for tag with spaces")
+	}
+}
+
+// tests collecting tags from frontmatter and inline, skipping code
+func TestExtract(t *testing.T) {
+	source := []byte("---\ntags: [lang/go, review]\ntitle: x\n---\n# Heading #notatag\n" +
+		"some #idea and #lang/go again, issue #12\n```\n#code\n```\nend #last/")
+
+	got := Extract(source)
+	want := []string{"lang/go", "review", "notatag", "idea", "last"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Extract() = %v, want %v", got, want)
+	}
+
+	got = Extract([]byte("---\ntags: a, b\n---\nbody"))
+	if strings.Join(got, ",") != "a,b" {
+		t.Errorf("expected string tags to be split, got %v", got)
+	}
+}
+
+// tests that a note with a tags: list gets the tag there, written like the list
+func TestAddToFrontmatter(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_tags_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tests := map[string]string{
+		"---\ntags: [a, b]\ntitle: x\n---\nbody":           "---\ntags: [a, b, go]\ntitle: x\n---\nbody",
+		"---\ntags: []\n---\nbody":                         "---\ntags: [go]\n---\nbody",
+		"---\ntags: a, b\n---\nbody":                       "---\ntags: a, b, go\n---\nbody",
+		"---\r\ntags:\r\n  - a\r\ntitle: x\r\n---\r\nbody": "---\r\ntags:\r\n  - a\r\n  - go\r\ntitle: x\r\n---\r\nbody",
+		"---\ntitle: x\n---\nbody":                         "---\ntitle: x\n---\nbody\n\n#go\n",
+	}
+	path := filepath.Join(tmpDir, "note.md")
+	for source, want := range tests {
+		os.WriteFile(path, []byte(source), 0644)
+		if err := Add(path, "go"); err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != want {
+			t.Errorf("Add() to %q = %q, want %q", source, data, want)
+		}
+	}
+}
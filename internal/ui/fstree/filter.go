@@ -0,0 +1,197 @@
+/*
+filter as you type inside the tree. while a filter is set only matching nodes
+and the folders leading to them are visible, everything else in the tree
+(navigation, rendering, line cache) goes through isVisible so it just works
+on the narrowed tree.
+*/
+
+package fstree
+
+import (
+	"strings"
+
+	"mend/internal/frontmatter"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (t *FsTree) IsFiltering() bool {
+	return t.filtering
+}
+
+func (t *FsTree) FilterQuery() string {
+	return t.filterQuery
+}
+
+func (t *FsTree) isVisible(node *FsNode) bool {
+	return t.kindVisible(node) && (t.visible == nil || t.visible[node])
+}
+
+// notes only mode hides files regardless of any filter
+func (t *FsTree) kindVisible(node *FsNode) bool {
+	return !t.notesOnly || node.Type != FileNode || node.isNote()
+}
+
+// StartFilter remembers how the tree looked so it can be put back later
+func (t *FsTree) StartFilter() {
+	if t.filtering {
+		return
+	}
+	t.filtering = true
+	t.filterQuery = ""
+	t.preFilterSelected = t.SelectedNode
+	t.savedExpanded = make(map[*FsNode]bool)
+	t.walkFolders(t.viewRoot(), func(folder *FsNode) {
+		t.savedExpanded[folder] = folder.Expanded
+	})
+}
+
+func (t *FsTree) SetFilter(query string) {
+	t.filterQuery = query
+	t.applyFilter()
+}
+
+// EndFilter drops the filter and restores expansion. When keep is set the
+// selected node stays selected and is revealed, otherwise the selection from
+// before filtering comes back.
+func (t *FsTree) EndFilter(keep bool) {
+	if !t.filtering {
+		return
+	}
+	selected := t.SelectedNode
+	t.filtering = false
+	t.filterQuery = ""
+	t.visible = nil
+	t.restoreExpanded()
+
+	if !keep || selected == nil {
+		selected = t.preFilterSelected
+	}
+	t.preFilterSelected = nil
+	t.savedExpanded = nil
+	if selected != nil && t.findNodeByPath(t.viewRoot(), selected.Path) == selected {
+		t.SelectByPath(selected.Path)
+	} else {
+		t.BuildLines()
+	}
+	t.viewStart, t.viewEnd = t.getViewportBounds()
+}
+
+func (t *FsTree) applyFilter() {
+	if t.filterQuery == "" {
+		t.visible = nil
+		t.restoreExpanded()
+		if t.SelectedNode == nil {
+			t.SelectedNode = t.preFilterSelected
+		}
+		t.BuildLines()
+		return
+	}
+
+	query := strings.ToLower(t.filterQuery)
+	t.visible = make(map[*FsNode]bool)
+	t.restoreExpanded() // so folders only opened for an earlier query close again
+	var walk func(node *FsNode)
+	walk = func(node *FsNode) {
+		for _, child := range node.Children {
+			if !t.kindVisible(child) {
+				continue
+			}
+			if filterMatches(child, query) {
+				// show the match and open up everything above it
+				t.visible[child] = true
+				for p := child.Parent; p != nil; p = p.Parent {
+					t.visible[p] = true
+					p.Expanded = true
+				}
+			}
+			walk(child)
+		}
+	}
+	walk(t.viewRoot())
+
+	if t.SelectedNode == nil || !t.visible[t.SelectedNode] {
+		t.SelectedNode = t.firstVisible(t.viewRoot())
+	}
+	t.BuildLines()
+}
+
+// the name, or for notes their frontmatter title or an alias
+func filterMatches(node *FsNode, query string) bool {
+	if strings.Contains(strings.ToLower(node.FileName()), query) {
+		return true
+	}
+	if !node.isNote() {
+		return false
+	}
+	meta := frontmatter.Read(node.Path)
+	for _, name := range append([]string{meta.Title}, meta.Aliases...) {
+		if name != "" && strings.Contains(strings.ToLower(name), query) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *FsTree) restoreExpanded() {
+	for folder, expanded := range t.savedExpanded {
+		folder.Expanded = expanded
+	}
+}
+
+func (t *FsTree) firstVisible(node *FsNode) *FsNode {
+	for _, child := range node.Children {
+		if !t.isVisible(child) {
+			continue
+		}
+		if t.visible[child] && filterMatches(child, strings.ToLower(t.filterQuery)) {
+			return child
+		}
+		if found := t.firstVisible(child); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// first node that would be drawn, nil if nothing is visible
+func (t *FsTree) firstVisibleNode(node *FsNode) *FsNode {
+	for _, child := range node.Children {
+		if t.isVisible(child) {
+			return child
+		}
+	}
+	return nil
+}
+
+func (t *FsTree) walkFolders(node *FsNode, fn func(folder *FsNode)) {
+	for _, child := range node.Children {
+		if child.Type == FolderNode {
+			fn(child)
+			t.walkFolders(child, fn)
+		}
+	}
+}
+
+// keys while the filter is being typed, everything else is text
+func (t *FsTree) updateFilter(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "esc":
+		t.EndFilter(false)
+	case "enter":
+		t.EndFilter(true)
+	case "up":
+		_ = t.MoveUp()
+	case "down":
+		_ = t.MoveDown()
+	case "backspace":
+		if len(t.filterQuery) > 0 {
+			runes := []rune(t.filterQuery)
+			t.SetFilter(string(runes[:len(runes)-1]))
+		}
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			t.SetFilter(t.filterQuery + string(msg.Runes))
+		}
+	}
+}
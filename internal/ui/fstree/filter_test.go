@@ -0,0 +1,101 @@
+package fstree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tests narrowing the tree and restoring it afterwards
+func TestFilter(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// setup structure:
+	// root/
+	//   top.md
+	//   folder1/ (collapsed)
+	//     deep/
+	//       golang.md
+	//   folder2/
+	//     other.md
+	os.MkdirAll(filepath.Join(tmpDir, "folder1", "deep"), 0755)
+	os.Mkdir(filepath.Join(tmpDir, "folder2"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "top.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "folder1", "deep", "golang.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "folder2", "other.md"), []byte(""), 0644)
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	folder1 := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "folder1"))
+	folder1.Expanded = false
+	top := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "top.md"))
+	tree.SelectedNode = top
+	tree.BuildLines()
+
+	tree.StartFilter()
+	tree.SetFilter("GO")
+
+	match := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "folder1", "deep", "golang.md"))
+	if !tree.isVisible(match) || !tree.isVisible(folder1) {
+		t.Error("expected match and its ancestors to be visible")
+	}
+	if tree.isVisible(top) || tree.isVisible(tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "folder2"))) {
+		t.Error("expected non matching nodes to be hidden")
+	}
+	if !folder1.Expanded {
+		t.Error("expected ancestor of match to be expanded")
+	}
+	if tree.SelectedNode != match {
+		t.Error("expected selection to jump to the match")
+	}
+
+	// cancelling restores expansion and selection
+	tree.EndFilter(false)
+	if folder1.Expanded {
+		t.Error("expected expansion state to be restored")
+	}
+	if tree.SelectedNode != top {
+		t.Error("expected selection to be restored")
+	}
+	if !tree.isVisible(top) {
+		t.Error("expected everything to be visible again")
+	}
+
+	// accepting keeps the match selected and revealed
+	tree.StartFilter()
+	tree.SetFilter("golang")
+	tree.EndFilter(true)
+	if tree.SelectedNode != match || !folder1.Expanded {
+		t.Error("expected accepted match to stay selected and revealed")
+	}
+}
+
+// tests selecting a note that only matches by an alias in its frontmatter
+func TestFilterAlias(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "lang.md"), []byte("---\naliases: [golang]\n---\n# Go\n"), 0644)
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	tree.StartFilter()
+	tree.SetFilter("golang")
+
+	match := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "lang.md"))
+	if !tree.isVisible(match) {
+		t.Fatal("expected the note to match by its alias")
+	}
+	if tree.SelectedNode != match {
+		t.Errorf("expected the alias match selected, got %v", tree.SelectedNode)
+	}
+	if view := tree.View(); view == "no matches" {
+		t.Error("expected the match shown, not no matches")
+	}
+}
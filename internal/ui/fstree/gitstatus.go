@@ -0,0 +1,46 @@
+/*
+git markers next to nodes. the status comes in from outside as a map of
+changed files, folders get the strongest status of anything inside them.
+*/
+
+package fstree
+
+import (
+	"path/filepath"
+
+	"mend/internal/git"
+	"mend/styles"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SetGitStatus replaces the markers, nil clears them
+func (t *FsTree) SetGitStatus(statuses map[string]git.Status) {
+	t.gitStatus = make(map[string]git.Status, len(statuses))
+	for path, status := range statuses {
+		t.gitStatus[path] = max(t.gitStatus[path], status)
+		// bubble up to every folder in the tree above it
+		for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+			if t.gitStatus[dir] >= status {
+				break
+			}
+			t.gitStatus[dir] = status
+		}
+	}
+	t.BuildLines() // markers change the width
+}
+
+func (t *FsTree) gitStatusOf(node *FsNode) git.Status {
+	return t.gitStatus[node.Path]
+}
+
+func renderGitMarker(status git.Status) string {
+	color := styles.GitStaged
+	switch status {
+	case git.Modified:
+		color = styles.GitModified
+	case git.Untracked:
+		color = styles.GitUntracked
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(status.Marker())
+}
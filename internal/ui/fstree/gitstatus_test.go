@@ -0,0 +1,46 @@
+package fstree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mend/internal/git"
+)
+
+// tests folders summing up the status of what's inside them
+func TestSetGitStatus(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.MkdirAll(filepath.Join(tmpDir, "folder", "deep"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "folder", "staged.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "folder", "deep", "changed.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "clean.md"), []byte(""), 0644)
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	width := tree.ContentWidth()
+	tree.SetGitStatus(map[string]git.Status{
+		filepath.Join(tmpDir, "folder", "staged.md"):          git.Staged,
+		filepath.Join(tmpDir, "folder", "deep", "changed.md"): git.Modified,
+	})
+
+	node := func(rel string) *FsNode {
+		return tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, rel))
+	}
+	if tree.gitStatusOf(node("folder")) != git.Modified {
+		t.Error("expected folder to take the strongest status inside it")
+	}
+	if tree.gitStatusOf(node(filepath.Join("folder", "staged.md"))) != git.Staged {
+		t.Error("expected file status to be kept")
+	}
+	if tree.gitStatusOf(node("clean.md")) != git.Unchanged {
+		t.Error("expected unchanged file to have no marker")
+	}
+	if tree.ContentWidth() <= width {
+		t.Error("expected markers to widen the tree")
+	}
+}
@@ -8,10 +8,14 @@ package fstree
 import (
 	"errors"
 	"mend/internal/filesystem"
+	"mend/internal/git"
+	"mend/internal/ignore"
+	"mend/internal/session"
 	"mend/styles"
 	"mend/utils"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -35,10 +39,21 @@ type FsNode struct {
 	Children []*FsNode
 	Parent   *FsNode // for fast traversal up the tree
 	Expanded bool    // makes sense only for folder nodes
+	Symlink  bool    // the path is a link, Type is what it points to
 	// these are populated by BuildLines for fast access
 	line         int
 	prevFlatNode *FsNode
 	nextFlatNode *FsNode
+	pinTarget    *FsNode // set only on the proxies in the pinned section
+}
+
+// Kind only makes sense for file nodes
+func (n *FsNode) Kind() filesystem.FileKind {
+	return filesystem.KindOf(n.Path)
+}
+
+func (n *FsNode) isNote() bool {
+	return n.Type == FileNode && n.Kind() == filesystem.KindNote
 }
 
 func (n *FsNode) FileName() string {
@@ -60,6 +75,9 @@ const (
 	ActionNewFile FsActionType = iota
 	ActionNewFolder
 	ActionNewRoot
+	ActionMove // name is the destination folder relative to root
+	ActionTag
+	ActionRename // name is the new file or folder name
 )
 
 type RequestInputMsg struct {
@@ -71,6 +89,21 @@ type PerformActionMsg struct {
 	Name   string
 }
 
+// NodesMovedMsg is sent after the tree moved nodes to new paths
+type NodesMovedMsg struct {
+	Moves []Move
+}
+
+// StartReviewMsg asks for a review of the given notes in order
+type StartReviewMsg struct {
+	Paths []string
+}
+
+// ExternalChangeMsg carries a change made on disk outside of mend (shell, git pull, ...)
+type ExternalChangeMsg struct {
+	Event filesystem.Event
+}
+
 // ==================== FsNode definition ====================
 type FsTree struct {
 	Root            *FsNode
@@ -86,6 +119,31 @@ type FsTree struct {
 	oldSelected     *FsNode
 	startOffset     int
 	maxContentWidth int
+	sortMode        SortMode
+	orders          map[*FsNode]session.Order // manual order of each root, kept loaded so switching to it is instant
+	roots           []*FsNode                 // workspace roots, just Root unless several were opened
+	rootNames       []string                  // how each root starts relative paths, see session.RootNames
+	// inline filter
+	filtering         bool
+	filterQuery       string
+	visible           map[*FsNode]bool // nil when everything is visible
+	savedExpanded     map[*FsNode]bool
+	preFilterSelected *FsNode
+	// multi selection
+	marked       map[*FsNode]bool
+	rangeAnchor  *FsNode
+	pendingMoves []Move
+	changed      []string // paths written, moved or deleted on disk since TakeChanged
+	notesOnly    bool     // hide everything that isn't a note
+	ignore       *ignore.Matcher
+	// pinned section above the tree
+	pins          []*FsNode
+	treeStartLine int // first line of the tree below the pins
+	gitStatus     map[string]git.Status
+	pendingG      bool // first g of gg was typed
+	// tags view
+	tagRoot      *FsNode // shown instead of Root while set
+	fileSelected *FsNode // selection in the file tree to go back to
 }
 
 func (t *FsTree) ContentWidth() int {
@@ -100,6 +158,7 @@ func (t *FsTree) Init() tea.Cmd {
 type ContentSizeChangeMsg struct{}
 
 func (t *FsTree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var sizeCmd tea.Cmd
 	switch m := msg.(type) {
 	case PerformActionMsg:
 		err := t.PerformAction(m.Action, m.Name)
@@ -108,12 +167,31 @@ func (t *FsTree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return t, nil
 		}
 		// todo: standardise these messages
-		return t, func() tea.Msg { return ContentSizeChangeMsg{} }
+		cmds := []tea.Cmd{func() tea.Msg { return ContentSizeChangeMsg{} }}
+		if moves := t.takeMoves(); len(moves) > 0 {
+			cmds = append(cmds, func() tea.Msg { return NodesMovedMsg{Moves: moves} })
+		}
+		return t, tea.Batch(cmds...)
+	case ExternalChangeMsg:
+		if !t.ApplyFsEvent(m.Event) {
+			return t, nil
+		}
+		sizeCmd = func() tea.Msg { return ContentSizeChangeMsg{} }
 	case tea.WindowSizeMsg:
 		t.width = m.Width
 		t.height = m.Height
 	case tea.KeyMsg:
 		t.ErrMsg = ""
+		if t.filtering {
+			t.updateFilter(m)
+			break
+		}
+		pendingG := t.pendingG
+		t.pendingG = false
+		if t.tagRoot != nil && editsTree(m.String()) {
+			t.ErrMsg = "not available in tags view"
+			break
+		}
 		switch m.String() {
 		case "w", "up":
 			_ = t.MoveUp()
@@ -123,19 +201,101 @@ func (t *FsTree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			_ = t.MovePgUp()
 		case "pgdown":
 			_ = t.MovePgDown()
+		case "ctrl+u":
+			_ = t.MoveHalfPage(-1)
+		case "ctrl+d":
+			_ = t.MoveHalfPage(1)
+		case "home":
+			_ = t.MoveToFirst()
+		case "end", "G":
+			_ = t.MoveToLast()
+		case "g": // gg like vim
+			if pendingG {
+				_ = t.MoveToFirst()
+			} else {
+				t.pendingG = true
+			}
+		case "backspace":
+			_ = t.MoveToParent()
+		case "-":
+			t.CollapseAll()
+		case "+", "=":
+			t.ExpandAll()
+		case "E":
+			if err := t.ExpandRecursive(t.SelectedNode); err != nil {
+				t.ErrMsg = err.Error()
+			}
 		case "e", "space":
+			if t.SelectedNode.isPin() {
+				t.SelectByPath(t.SelectedNode.pinTarget.Path) // jump to it in the tree
+				break
+			}
 			_ = t.ToggleSelectedExpand()
+		case "/":
+			t.StartFilter()
+		case ".": // toggle non note files
+			t.SetNotesOnly(!t.notesOnly)
+		case "S": // cycle sort mode
+			t.CycleSortMode()
+		case "t": // files <-> tags
+			t.ToggleTagsMode()
+		case "alt+up", "alt+w":
+			if err := t.MoveInManualOrder(-1); err != nil {
+				t.ErrMsg = err.Error()
+			}
+		case "alt+down", "alt+s":
+			if err := t.MoveInManualOrder(1); err != nil {
+				t.ErrMsg = err.Error()
+			}
 		case "n": // new file
 			return t, func() tea.Msg { return RequestInputMsg{Action: ActionNewFile} }
 		case "N": // new folder
 			return t, func() tea.Msg { return RequestInputMsg{Action: ActionNewFolder} }
 		case "C": // new root node
 			return t, func() tea.Msg { return RequestInputMsg{Action: ActionNewRoot} }
-		case "delete": // delete node
-			err := t.DeleteNode(t.SelectedNode)
+		case "D": // duplicate
+			if err := t.DuplicateNode(t.SelectedNode); err != nil {
+				t.ErrMsg = err.Error()
+				break
+			}
+			sizeCmd = func() tea.Msg { return ContentSizeChangeMsg{} } // the copy's name is longer
+		case "delete": // delete node, or everything marked
+			if t.SelectedNode.isPin() {
+				t.TogglePin() // only ever unpins, the note stays
+				break
+			}
+			err := t.DeleteSelection()
 			if err != nil {
 				t.ErrMsg = err.Error()
 			}
+		case "x": // mark for bulk actions
+			if t.SelectedNode != nil {
+				t.ToggleMark(resolve(t.SelectedNode))
+			}
+		case "p": // pin to the top
+			t.TogglePin()
+		case "shift+up":
+			_ = t.ExtendSelection(-1)
+		case "shift+down":
+			_ = t.ExtendSelection(1)
+		case "esc":
+			t.ClearMarks()
+		case "M": // move selection
+			return t, func() tea.Msg { return RequestInputMsg{Action: ActionMove} }
+		case "m", "f2": // rename
+			if t.SelectedNode != nil {
+				return t, func() tea.Msg { return RequestInputMsg{Action: ActionRename} }
+			}
+		case "T": // tag selection
+			return t, func() tea.Msg { return RequestInputMsg{Action: ActionTag} }
+		case "R": // review selection
+			paths := t.SelectedNotePaths()
+			if len(paths) == 0 {
+				t.ErrMsg = "no notes in selection to review"
+				break
+			}
+			t.ClearMarks()
+			return t, func() tea.Msg { return StartReviewMsg{Paths: paths} }
 		}
 
 	case tea.MouseMsg:
@@ -158,7 +318,11 @@ func (t *FsTree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// click
 		if m.Button == tea.MouseButtonLeft && m.Action == tea.MouseActionPress {
 			nodeAtLine := t.lines[m.Y]
-			if nodeAtLine != nil {
+			if nodeAtLine != nil && m.Ctrl && t.tagRoot == nil {
+				t.ToggleMark(nodeAtLine)
+			} else if nodeAtLine.isPin() && nodeAtLine.Type == FolderNode {
+				t.SelectByPath(nodeAtLine.pinTarget.Path)
+			} else if nodeAtLine != nil {
 				t.SelectedNode = nodeAtLine
 				if nodeAtLine.Type == FolderNode {
 					_ = t.ToggleExpand(nodeAtLine)
@@ -169,23 +333,38 @@ func (t *FsTree) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	t.viewStart, t.viewEnd = t.getViewportBounds()
 
-	if t.oldSelected != t.SelectedNode && t.SelectedNode.Type == FileNode {
+	if t.SelectedNode != nil && t.oldSelected != t.SelectedNode && t.SelectedNode.Type == FileNode {
 		t.oldSelected = t.SelectedNode
-		return t, func() tea.Msg {
-			return NodeSelectedMsg{Path: t.SelectedNode.Path}
-		}
+		path := t.SelectedNode.Path
+		return t, tea.Batch(sizeCmd, func() tea.Msg {
+			return NodeSelectedMsg{Path: path}
+		})
 	}
-	return t, nil
+	return t, sizeCmd
 }
 
 func (t *FsTree) PerformAction(action FsActionType, name string) error {
+	if t.SelectedNode.isPin() {
+		// acting on a pin is acting on what it points to
+		t.SelectedNode = t.SelectedNode.pinTarget
+		t.oldSelected = t.SelectedNode
+	}
 	switch action {
 	case ActionNewFile:
 		return t.CreateNode(t.SelectedNode, name, FileNode)
 	case ActionNewFolder:
 		return t.CreateNode(t.SelectedNode, name, FolderNode)
 	case ActionNewRoot:
-		return t.CreateNode(t.Root, name, FolderNode)
+		return t.CreateNode(t.currentRoot(), name, FolderNode)
+	case ActionMove:
+		return t.MoveSelection(name)
+	case ActionRename:
+		return t.RenameNode(t.SelectedNode, name)
+	case ActionTag:
+		if err := t.TagSelection(name); err != nil {
+			return err
+		}
+		t.RefreshTags()
 	}
 	return nil
 }
@@ -216,12 +395,20 @@ func (t *FsTree) View() string {
 		return t.ErrMsg
 	}
 
-	if len(t.Root.Children) == 0 {
+	if len(t.viewRoot().Children) == 0 {
+		if t.tagRoot != nil {
+			return "no tagged notes\nPress t for files"
+		}
 		return "no files/folders\nPress C to create"
 	}
 
+	if t.filtering && t.SelectedNode == nil {
+		return "no matches"
+	}
+
 	builder := &strings.Builder{}
-	t.renderNode(t.Root, 0, builder)
+	t.renderPins(builder)
+	t.renderNode(t.viewRoot(), 0, builder)
 	rendered := builder.String()
 
 	lines := strings.Split(rendered, "\n")
@@ -238,40 +425,38 @@ func (t *FsTree) View() string {
 
 // ==================== FsTree helper methods ====================
 
-func NewFsTree(rootPath string, startOffset int) *FsTree {
-	root := &FsNode{
-		Type:     FolderNode,
-		Path:     rootPath,
-		Children: make([]*FsNode, 0),
-		Expanded: true,
-	}
-	WalkFileSystemAndBuildTree(rootPath, root)
+// matcher decides what is left out besides dot files, nil for nothing
+func NewFsTree(rootPath string, startOffset int, matcher *ignore.Matcher) *FsTree {
+	return NewWorkspaceTree([]string{rootPath}, startOffset, matcher)
+}
 
-	tree := &FsTree{
-		Root:        root,
-		startOffset: startOffset,
+// checkDelete says why a node can't be deleted, before anything is touched
+func (t *FsTree) checkDelete(node *FsNode) error {
+	if node == nil {
+		return errors.New("node to delete cannot be nil")
 	}
-	if len(root.Children) > 0 {
-		tree.SelectedNode = root.Children[0]
+	if node.Parent == nil {
+		return errors.New("node to delete must have a parent")
+	}
+	if slices.Contains(t.roots, node) {
+		return errors.New("a workspace root cannot be deleted")
 	}
-	tree.BuildLines()
-	return tree
+	return nil
 }
 
 func (t *FsTree) DeleteNode(node *FsNode) error {
-	if node == nil {
-		return errors.New("node to delete cannot be nil")
+	if err := t.checkDelete(node); err != nil {
+		return err
 	}
 	parent := node.Parent
-	if parent == nil {
-		return errors.New("node to delete must have a parent")
-	}
 
 	// materialise
 	if err := filesystem.DeletePath(node.Path); err != nil {
 		return err
 	}
+	t.changed = append(t.changed, node.Path)
 
+	t.unmarkSubtree(node)
 	t.SelectedNode = node.prevFlatNode // cannot be next as subfolder/file deletion
 	parent.Children = utils.RemoveFromSlice(parent.Children, node)
 
@@ -324,26 +509,26 @@ func (t *FsTree) MoveUp() error   { return t.move(-1) }
 func (t *FsTree) MoveDown() error { return t.move(1) }
 
 func (t *FsTree) MovePgUp() error {
-	if t.SelectedNode == nil || t.Root == nil {
+	if t.SelectedNode == nil || t.viewRoot() == nil {
 		// TODO: make these errors not string based
 		// again, not expected
 		return errors.New("no node is currently selected or root is nil")
 	}
 
 	// Find the ancestor that is a direct child of Root
-	curr := t.SelectedNode
-	for curr.Parent != nil && curr.Parent != t.Root {
+	curr := resolve(t.SelectedNode)
+	for curr.Parent != nil && curr.Parent != t.viewRoot() {
 		curr = curr.Parent
 	}
 
-	if curr.Parent != t.Root {
+	if curr.Parent != t.viewRoot() {
 		// shoudl not happen
 		return errors.New("selected node is not a direct child of root")
 	}
 
 	// Find index of curr in Root.Children
 	idx := -1
-	for i, child := range t.Root.Children {
+	for i, child := range t.viewRoot().Children {
 		if child == curr {
 			idx = i
 			break
@@ -351,38 +536,38 @@ func (t *FsTree) MovePgUp() error {
 	}
 
 	if idx > 0 {
-		t.SelectedNode = t.Root.Children[idx-1]
+		t.SelectedNode = t.viewRoot().Children[idx-1]
 	}
 
 	return nil
 }
 
 func (t *FsTree) MovePgDown() error {
-	if t.SelectedNode == nil || t.Root == nil {
+	if t.SelectedNode == nil || t.viewRoot() == nil {
 		return errors.New("no node is currently selected or root is nil")
 	}
 
 	// up till root
-	curr := t.SelectedNode
-	for curr.Parent != nil && curr.Parent != t.Root {
+	curr := resolve(t.SelectedNode)
+	for curr.Parent != nil && curr.Parent != t.viewRoot() {
 		curr = curr.Parent
 	}
 
-	if curr.Parent != t.Root {
+	if curr.Parent != t.viewRoot() {
 		return errors.New("selected node is not a direct child of root")
 	}
 
 	// Find index of curr in Root.Children
 	idx := -1
-	for i, child := range t.Root.Children {
+	for i, child := range t.viewRoot().Children {
 		if child == curr {
 			idx = i
 			break
 		}
 	}
 
-	if idx < len(t.Root.Children)-1 {
-		t.SelectedNode = t.Root.Children[idx+1]
+	if idx < len(t.viewRoot().Children)-1 {
+		t.SelectedNode = t.viewRoot().Children[idx+1]
 	}
 
 	return nil
@@ -402,7 +587,7 @@ func (t *FsTree) ToggleSelectedExpand() error {
 
 // SelectByPath finds and selects a node by its file system path
 func (t *FsTree) SelectByPath(path string) bool {
-	node := t.findNodeByPath(t.Root, path)
+	node := t.findNodeByPath(t.viewRoot(), path)
 	if node != nil {
 		// Expand all parent folders to make the node visible
 		parent := node.Parent
@@ -419,6 +604,68 @@ func (t *FsTree) SelectByPath(path string) bool {
 	return false
 }
 
+func (t *FsTree) NotesOnly() bool {
+	return t.notesOnly
+}
+
+// SetNotesOnly hides or shows non note files, moving the selection off a hidden node
+func (t *FsTree) SetNotesOnly(notesOnly bool) {
+	t.notesOnly = notesOnly
+	if t.SelectedNode != nil && !t.isVisible(t.SelectedNode) {
+		t.SelectedNode = t.firstVisibleNode(t.viewRoot())
+	}
+	t.BuildLines()
+	t.viewStart, t.viewEnd = t.getViewportBounds()
+}
+
+// RestoreSelection selects a node without it counting as a new selection,
+// used when the open note is restored separately
+func (t *FsTree) RestoreSelection(path string) bool {
+	if !t.SelectByPath(path) {
+		return false
+	}
+	t.oldSelected = t.SelectedNode
+	t.viewStart, t.viewEnd = t.getViewportBounds()
+	return true
+}
+
+// ExpandedPaths lists every expanded folder below root
+func (t *FsTree) ExpandedPaths() []string {
+	paths := make([]string, 0)
+	var walk func(node *FsNode)
+	walk = func(node *FsNode) {
+		for _, child := range node.Children {
+			if child.Type == FolderNode {
+				if child.Expanded {
+					paths = append(paths, child.Path)
+				}
+				walk(child)
+			}
+		}
+	}
+	walk(t.Root)
+	return paths
+}
+
+// RestoreExpanded expands exactly the given folders and collapses the rest
+func (t *FsTree) RestoreExpanded(paths []string) {
+	expanded := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		expanded[p] = true
+	}
+	var walk func(node *FsNode)
+	walk = func(node *FsNode) {
+		for _, child := range node.Children {
+			if child.Type == FolderNode {
+				child.Expanded = expanded[child.Path]
+				walk(child)
+			}
+		}
+	}
+	walk(t.Root)
+	t.BuildLines()
+}
+
 // tree walks and find where patch matches, think can easily be better
 // if I cache it in an associative continer but this is fine too
 func (t *FsTree) findNodeByPath(node *FsNode, path string) *FsNode {
@@ -437,12 +684,12 @@ func (t *FsTree) findNodeByPath(node *FsNode, path string) *FsNode {
 }
 
 func (t *FsTree) renderNode(node *FsNode, depth int, builder *strings.Builder) {
-	if node == nil {
+	if node == nil || !t.isVisible(node) {
 		return
 	}
 
 	folderInRoot := false
-	if node.Type == FolderNode && depth == 1 && node.prevFlatNode != nil {
+	if node.Type == FolderNode && depth == 1 && node.line != t.treeStartLine {
 		folderInRoot = true
 	}
 
@@ -460,11 +707,14 @@ func (t *FsTree) renderNode(node *FsNode, depth int, builder *strings.Builder) {
 	case FileNode:
 		icon = styles.VerticalLine
 		icon = lipgloss.NewStyle().Faint(true).Render(prevIndent + icon + " ")
+		if !node.isNote() {
+			icon += lipgloss.NewStyle().Foreground(styles.AttachmentGray).Render(styles.KindIcon(styles.Kind(node.Kind().String()))) + " "
+		}
 	}
 
 	// highlight if selected or hovered
 	// note: the logic of lines cache needs to match render
-	fileName := node.FileName()
+	fileName := t.displayName(node)
 	isSelected := node == t.SelectedNode
 	isHovered := node == t.hoveredNode
 
@@ -473,6 +723,16 @@ func (t *FsTree) renderNode(node *FsNode, depth int, builder *strings.Builder) {
 	} else if isHovered {
 		fileName = lipgloss.NewStyle().Foreground(styles.HoverHighlight).Render(fileName)
 	}
+	if t.marked[node] {
+		fileName = lipgloss.NewStyle().Foreground(styles.Primary).Render(styles.MarkIcon) + " " + fileName
+	}
+
+	if node.Symlink {
+		fileName += " " + lipgloss.NewStyle().Faint(true).Render(styles.SymlinkIcon)
+	}
+	if status := t.gitStatusOf(node); status != git.Unchanged {
+		fileName += " " + renderGitMarker(status)
+	}
 
 	line := icon + " " + fileName + "\n"
 
@@ -494,11 +754,14 @@ func (t *FsTree) renderNode(node *FsNode, depth int, builder *strings.Builder) {
 func (t *FsTree) BuildLines() {
 	t.maxContentWidth = 0
 	t.lines = make(map[int]*FsNode)
-	line := -1
-	flatTree := make([]*FsNode, 0)
-	t.buildLinesRec(t.Root, 0, &line, &flatTree) // root has -1 depth and -1 index, as it's not meant to be rendered
-	// everything is a child of root
-	flatTree[0] = nil // basically a merge of skip root and padding ends with nil
+	flatTree := []*FsNode{nil} // padding at both ends with nil
+	line := t.buildPinLines(&flatTree)
+	t.treeStartLine = line
+	pinCount := len(flatTree) - 1
+
+	line-- // root is not meant to be rendered, its first child goes on treeStartLine
+	t.buildLinesRec(t.viewRoot(), 0, &line, &flatTree)
+	flatTree = append(flatTree[:pinCount+1], flatTree[pinCount+2:]...) // skip root
 	flatTree = append(flatTree, nil)
 	t.totalLines = line
 
@@ -510,21 +773,35 @@ func (t *FsTree) BuildLines() {
 
 // isn't meant to be used directly
 func (t *FsTree) buildLinesRec(node *FsNode, depth int, currentLine *int, flatTree *[]*FsNode) {
-	if node == nil {
+	if node == nil || !t.isVisible(node) {
 		return
 	}
 
-	if node.Type == FolderNode && depth == 1 && *currentLine != 0 {
+	if node.Type == FolderNode && depth == 1 && *currentLine != t.treeStartLine {
 		(*currentLine)++
 	}
-	t.lines[*currentLine] = node
+	if depth > 0 {
+		t.lines[*currentLine] = node
+	}
 	node.line = *currentLine
 	*flatTree = append(*flatTree, node)
 	(*currentLine)++
 
 	// update max width
 	if depth > 0 {
-		w := depth + 2 + len(node.FileName())
+		w := depth + 2 + len(t.displayName(node))
+		if t.marked[node] {
+			w += 2
+		}
+		if node.Type == FileNode && !node.isNote() {
+			w += 2
+		}
+		if t.gitStatusOf(node) != git.Unchanged {
+			w += 2
+		}
+		if node.Symlink {
+			w += 2
+		}
 		if w > t.maxContentWidth {
 			t.maxContentWidth = w
 		}
@@ -575,6 +852,8 @@ func (t *FsTree) CreateNode(folder *FsNode, name string, nodeType FsNodeType) er
 		}
 	}
 
+	t.changed = append(t.changed, path)
+
 	expanded := false
 	if nodeType == FolderNode {
 		expanded = true
@@ -586,19 +865,150 @@ func (t *FsTree) CreateNode(folder *FsNode, name string, nodeType FsNodeType) er
 		Parent:   folder,
 		Expanded: expanded,
 	}
-	// files are first of children, folder last of children
-	if nodeType == FileNode {
-		folder.Children = append([]*FsNode{newNode}, folder.Children...)
-	} else {
-		folder.Children = append(folder.Children, newNode)
-	}
+	t.insertChild(folder, newNode)
 	t.SelectedNode = newNode
 	t.BuildLines()
 
 	return nil
 }
 
-func WalkFileSystemAndBuildTree(rootPath string, node *FsNode) error {
+// DuplicateNode copies a note or a whole folder next to itself and selects the copy
+func (t *FsTree) DuplicateNode(node *FsNode) error {
+	node = resolve(node)
+	if node == nil || node.Parent == nil {
+		return errors.New("no node is currently selected")
+	}
+	if slices.Contains(t.roots, node) {
+		return errors.New("a workspace root cannot be duplicated")
+	}
+
+	path := filesystem.CopyName(node.Path)
+	if err := filesystem.CopyPath(node.Path, path); err != nil {
+		return err
+	}
+	t.changed = append(t.changed, path)
+
+	newNode := &FsNode{
+		Type:     node.Type,
+		Path:     path,
+		Children: make([]*FsNode, 0),
+		Parent:   node.Parent,
+		Expanded: node.Expanded,
+		Symlink:  node.Symlink,
+	}
+	if node.Type == FolderNode {
+		WalkFileSystemAndBuildTree(path, newNode, t.ignore)
+	}
+	t.insertChild(node.Parent, newNode)
+	t.SelectedNode = newNode
+	t.BuildLines()
+	return nil
+}
+
+// ApplyFsEvent patches the tree in place for a change that happened on disk.
+// Expansion state, selection and hence the scroll position are left alone unless
+// the selected node itself went away. Returns false if nothing changed, which is
+// also the case for our own creates/deletes as they are already in the tree.
+func (t *FsTree) ApplyFsEvent(ev filesystem.Event) bool {
+	switch ev.Op {
+	case filesystem.EventCreate:
+		if t.findNodeByPath(t.Root, ev.Path) != nil {
+			return false
+		}
+		parent := t.findNodeByPath(t.Root, filepath.Dir(ev.Path))
+		if parent == nil || parent.Type != FolderNode {
+			return false
+		}
+		info, err := os.Lstat(ev.Path)
+		if err != nil {
+			return false // already gone again
+		}
+		symlink := info.Mode()&os.ModeSymlink != 0
+		isDir := info.IsDir()
+		if target, err := os.Stat(ev.Path); symlink && err == nil {
+			isDir = target.IsDir() // broken links stay files like in the walk
+		}
+		if t.ignore.Ignored(ev.Path, isDir) {
+			return false
+		}
+		newNode := &FsNode{
+			Type:     FileNode,
+			Path:     ev.Path,
+			Children: make([]*FsNode, 0),
+			Parent:   parent, // for loop detection while walking it
+			Symlink:  symlink,
+		}
+		if isDir {
+			newNode.Type = FolderNode
+			newNode.Expanded = true
+			WalkFileSystemAndBuildTree(ev.Path, newNode, t.ignore)
+		}
+		t.insertChild(parent, newNode)
+		if t.SelectedNode == nil {
+			t.SelectedNode = newNode
+		}
+
+	case filesystem.EventRemove:
+		node := t.findNodeByPath(t.Root, ev.Path)
+		if node == nil || node.Parent == nil || slices.Contains(t.roots, node) {
+			return false
+		}
+		if isAncestorOrSelf(node, t.SelectedNode) {
+			t.SelectedNode = node.prevFlatNode
+		}
+		if isAncestorOrSelf(node, t.hoveredNode) {
+			t.hoveredNode = nil
+		}
+		node.Parent.Children = utils.RemoveFromSlice(node.Parent.Children, node)
+		if t.SelectedNode == nil && len(t.Root.Children) > 0 {
+			t.SelectedNode = t.Root.Children[0]
+		}
+		delete(t.savedExpanded, node)
+		t.unmarkSubtree(node)
+		if t.preFilterSelected != nil && isAncestorOrSelf(node, t.preFilterSelected) {
+			t.preFilterSelected = nil
+		}
+		if t.fileSelected != nil && isAncestorOrSelf(node, t.fileSelected) {
+			t.fileSelected = nil
+		}
+	}
+
+	if t.tagRoot != nil {
+		t.RefreshTags() // filters and rebuilds the lines itself
+		return true
+	}
+
+	if t.filtering {
+		t.applyFilter() // new nodes may match, removed ones may have been the only match
+		return true
+	}
+	t.BuildLines()
+	return true
+}
+
+func isAncestorOrSelf(ancestor, node *FsNode) bool {
+	for n := node; n != nil; n = n.Parent {
+		if n == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// WalkFileSystemAndBuildTree fills node with what is on disk below rootPath.
+// Links to folders are followed unless they lead back into a folder already
+// on the way down, those stay as an empty folder marked as a link.
+func WalkFileSystemAndBuildTree(rootPath string, node *FsNode, matcher *ignore.Matcher) error {
+	guard := filesystem.NewLoopGuard()
+	for p := node.Parent; p != nil; p = p.Parent {
+		if p.Path != "" { // virtual root of several roots
+			guard.Enter(p.Path)
+		}
+	}
+	return walkTree(rootPath, node, matcher, guard)
+}
+
+func walkTree(rootPath string, node *FsNode, matcher *ignore.Matcher, guard *filesystem.LoopGuard) error {
 	if node == nil {
 		return errors.New("node cannot be nil")
 	}
@@ -606,21 +1016,31 @@ func WalkFileSystemAndBuildTree(rootPath string, node *FsNode) error {
 		return errors.New("node already has children")
 	}
 
-	entries, err := os.ReadDir(rootPath)
+	leave, ok := guard.Enter(rootPath)
+	if !ok {
+		node.Expanded = false
+		return nil // would loop
+	}
+	defer leave()
+
+	entries, err := filesystem.ReadDir(rootPath)
 	if err != nil {
 		return err
 	}
 
-	files := make([]os.DirEntry, 0)
-	folders := make([]os.DirEntry, 0)
+	files := make([]filesystem.Entry, 0)
+	folders := make([]filesystem.Entry, 0)
 
 	for _, entry := range entries {
 		// dot folders and files skipped
-		if len(entry.Name()) > 0 && entry.Name()[0] == '.' {
+		if len(entry.Name) > 0 && entry.Name[0] == '.' {
+			continue
+		}
+		if matcher.Ignored(entry.Path, entry.IsDir) {
 			continue
 		}
 
-		if entry.IsDir() {
+		if entry.IsDir {
 			folders = append(folders, entry)
 		} else {
 			files = append(files, entry)
@@ -630,10 +1050,11 @@ func WalkFileSystemAndBuildTree(rootPath string, node *FsNode) error {
 	for _, file := range files {
 		newNode := &FsNode{
 			Type:     FileNode,
-			Path:     filepath.Join(rootPath, file.Name()),
+			Path:     file.Path,
 			Children: make([]*FsNode, 0),
 			Parent:   node,
 			Expanded: false,
+			Symlink:  file.Symlink,
 		}
 		node.Children = append(node.Children, newNode)
 	}
@@ -641,13 +1062,14 @@ func WalkFileSystemAndBuildTree(rootPath string, node *FsNode) error {
 	for _, folder := range folders {
 		newNode := &FsNode{
 			Type:     FolderNode,
-			Path:     filepath.Join(rootPath, folder.Name()),
+			Path:     folder.Path,
 			Children: make([]*FsNode, 0),
 			Parent:   node,
 			Expanded: true, // all expanded by default
+			Symlink:  folder.Symlink,
 		}
 		node.Children = append(node.Children, newNode)
-		WalkFileSystemAndBuildTree(newNode.Path, newNode)
+		walkTree(newNode.Path, newNode, matcher, guard)
 	}
 
 	return nil
@@ -1,6 +1,8 @@
 package fstree
 
 import (
+	"mend/internal/filesystem"
+	"mend/internal/ignore"
 	"os"
 	"path/filepath"
 	"testing"
@@ -24,7 +26,7 @@ func TestNewFsTree(t *testing.T) {
 	os.WriteFile(filepath.Join(tmpDir, "folder1", "file1.md"), []byte(""), 0644)
 	os.WriteFile(filepath.Join(tmpDir, "file2.md"), []byte(""), 0644)
 
-	tree := NewFsTree(tmpDir, 0)
+	tree := NewFsTree(tmpDir, 0, nil)
 
 	if tree == nil {
 		t.Fatal("expected tree to be created, got nil")
@@ -46,7 +48,7 @@ func TestTreeCreateNode(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	tree := NewFsTree(tmpDir, 0)
+	tree := NewFsTree(tmpDir, 0, nil)
 	// create folder
 	err = tree.CreateNode(tree.Root, "new_folder", FolderNode)
 	if err != nil {
@@ -89,7 +91,7 @@ func TestTreeDeleteNode(t *testing.T) {
 	// setup: root/file.md
 	os.WriteFile(filepath.Join(tmpDir, "file.md"), []byte(""), 0644)
 
-	tree := NewFsTree(tmpDir, 0)
+	tree := NewFsTree(tmpDir, 0, nil)
 	targetNode := tree.Root.Children[0]
 
 	err = tree.DeleteNode(targetNode)
@@ -145,3 +147,183 @@ func TestTreeToggleExpand(t *testing.T) {
 		t.Error("expected node to be expanded")
 	}
 }
+
+// tests patching the tree for changes made outside the app
+func TestTreeApplyFsEvent(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// setup: root/folder1/file1.md, root/file2.md
+	os.Mkdir(filepath.Join(tmpDir, "folder1"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "folder1", "file1.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "file2.md"), []byte(""), 0644)
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	folder := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "folder1"))
+	folder.Expanded = false
+	tree.SelectedNode = folder
+	tree.BuildLines()
+
+	// create a new file externally
+	newPath := filepath.Join(tmpDir, "folder1", "new.md")
+	os.WriteFile(newPath, []byte(""), 0644)
+	if !tree.ApplyFsEvent(filesystem.Event{Op: filesystem.EventCreate, Path: newPath}) {
+		t.Fatal("expected create event to change the tree")
+	}
+	if tree.findNodeByPath(tree.Root, newPath) == nil {
+		t.Error("new file not added to tree")
+	}
+	if folder.Expanded {
+		t.Error("expansion state should be preserved")
+	}
+	if tree.SelectedNode != folder {
+		t.Error("selection should be preserved")
+	}
+
+	// second create for the same path is a noop
+	if tree.ApplyFsEvent(filesystem.Event{Op: filesystem.EventCreate, Path: newPath}) {
+		t.Error("expected duplicate create to be ignored")
+	}
+
+	// removing the selected folder moves selection up
+	os.RemoveAll(filepath.Join(tmpDir, "folder1"))
+	if !tree.ApplyFsEvent(filesystem.Event{Op: filesystem.EventRemove, Path: folder.Path}) {
+		t.Fatal("expected remove event to change the tree")
+	}
+	if len(tree.Root.Children) != 1 {
+		t.Errorf("expected 1 child after removal, got %d", len(tree.Root.Children))
+	}
+	if tree.SelectedNode == nil || tree.SelectedNode.Path != filepath.Join(tmpDir, "file2.md") {
+		t.Error("expected selection to move to remaining node")
+	}
+}
+
+// tests hiding non note files
+func TestTreeNotesOnly(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "a.png"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "b.md"), []byte(""), 0644)
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	image := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "a.png"))
+	if image.Kind() != filesystem.KindImage || image.FileName() != "a.png" {
+		t.Errorf("unexpected kind %v or name %s for image", image.Kind(), image.FileName())
+	}
+	tree.SelectedNode = image
+
+	tree.SetNotesOnly(true)
+	if tree.isVisible(image) {
+		t.Error("expected image to be hidden")
+	}
+	if tree.SelectedNode == image || tree.SelectedNode == nil {
+		t.Error("expected selection to move off the hidden node")
+	}
+
+	tree.SetNotesOnly(false)
+	if !tree.isVisible(image) {
+		t.Error("expected image to be visible again")
+	}
+}
+
+// tests that ignore rules apply when building and patching the tree
+func TestTreeIgnore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.Mkdir(filepath.Join(tmpDir, "node_modules"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "node_modules", "readme.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "note.md"), []byte(""), 0644)
+
+	matcher := ignore.FromPatterns(tmpDir, "node_modules/", "*.log")
+	tree := NewFsTree(tmpDir, 0, matcher)
+	if len(tree.Root.Children) != 1 || tree.Root.Children[0].FileName() != "note" {
+		t.Errorf("expected only note.md in tree, got %d children", len(tree.Root.Children))
+	}
+
+	logPath := filepath.Join(tmpDir, "debug.log")
+	os.WriteFile(logPath, []byte(""), 0644)
+	if tree.ApplyFsEvent(filesystem.Event{Op: filesystem.EventCreate, Path: logPath}) {
+		t.Error("expected ignored file to stay out of the tree")
+	}
+}
+
+// tests following folder links without looping
+func TestTreeSymlinks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// setup structure:
+	// root/
+	//   shared/
+	//     a.md
+	//     loop -> root
+	//   link -> shared
+	os.Mkdir(filepath.Join(tmpDir, "shared"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "shared", "a.md"), []byte(""), 0644)
+	if err := os.Symlink(filepath.Join(tmpDir, "shared"), filepath.Join(tmpDir, "link")); err != nil {
+		t.Skip("symlinks not supported")
+	}
+	os.Symlink(tmpDir, filepath.Join(tmpDir, "shared", "loop"))
+
+	tree := NewFsTree(tmpDir, 0, nil)
+
+	link := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "link"))
+	if link == nil || link.Type != FolderNode || !link.Symlink {
+		t.Fatal("expected link to show as a linked folder")
+	}
+	if tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "link", "a.md")) == nil {
+		t.Error("expected linked folder to be followed")
+	}
+	loop := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "shared", "loop"))
+	if loop == nil || !loop.Symlink || len(loop.Children) != 0 {
+		t.Error("expected link back to the root to stay empty")
+	}
+}
+
+// tests duplicating a note and a folder next to the original
+func TestDuplicateNode(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.Mkdir(filepath.Join(tmpDir, "weekly"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "weekly", "template.md"), []byte("# week"), 0644)
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	template := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "weekly", "template.md"))
+	if err := tree.DuplicateNode(template); err != nil {
+		t.Fatal(err)
+	}
+	copyPath := filepath.Join(tmpDir, "weekly", "template copy.md")
+	if tree.SelectedNode == nil || tree.SelectedNode.Path != copyPath {
+		t.Fatal("expected the copy to be selected")
+	}
+	if data, _ := os.ReadFile(copyPath); string(data) != "# week" {
+		t.Error("expected the content to be copied")
+	}
+
+	folder := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "weekly"))
+	if err := tree.DuplicateNode(folder); err != nil {
+		t.Fatal(err)
+	}
+	copied := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "weekly copy"))
+	if copied == nil || len(copied.Children) != 2 || copied.Parent != tree.Root {
+		t.Error("expected the folder copy with both notes next to the original")
+	}
+}
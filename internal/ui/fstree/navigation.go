@@ -0,0 +1,98 @@
+/*
+keyboard navigation on top of the flat list: parent, first/last, half pages
+and expanding or collapsing in bulk. all of it only moves the cursor or flips
+Expanded, the viewport follows the cursor on its own.
+*/
+
+package fstree
+
+import "errors"
+
+// MoveToParent selects the folder the selected node is in
+func (t *FsTree) MoveToParent() error {
+	if t.SelectedNode == nil {
+		return errors.New("no node is currently selected")
+	}
+	parent := resolve(t.SelectedNode).Parent
+	if parent == nil || parent == t.viewRoot() {
+		return nil // already at the top
+	}
+	t.SelectedNode = parent
+	return nil
+}
+
+func (t *FsTree) MoveToFirst() error {
+	if t.SelectedNode == nil {
+		return errors.New("no node is currently selected")
+	}
+	for t.SelectedNode.prevFlatNode != nil {
+		t.SelectedNode = t.SelectedNode.prevFlatNode
+	}
+	return nil
+}
+
+func (t *FsTree) MoveToLast() error {
+	if t.SelectedNode == nil {
+		return errors.New("no node is currently selected")
+	}
+	for t.SelectedNode.nextFlatNode != nil {
+		t.SelectedNode = t.SelectedNode.nextFlatNode
+	}
+	return nil
+}
+
+// MoveHalfPage moves the cursor half the visible height up (-1) or down (1)
+func (t *FsTree) MoveHalfPage(delta int) error {
+	for range max(1, t.height/2) {
+		if err := t.move(delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CollapseAll closes every folder, the cursor moves up to a node that is still shown
+func (t *FsTree) CollapseAll() {
+	t.walkFolders(t.viewRoot(), func(folder *FsNode) {
+		folder.Expanded = false
+	})
+	if t.SelectedNode != nil && !t.SelectedNode.isPin() {
+		top := t.SelectedNode
+		for top.Parent != nil && top.Parent != t.viewRoot() {
+			top = top.Parent
+		}
+		t.SelectedNode = top
+	}
+	t.BuildLines()
+}
+
+func (t *FsTree) ExpandAll() {
+	t.walkFolders(t.viewRoot(), func(folder *FsNode) {
+		folder.Expanded = true
+	})
+	t.BuildLines()
+}
+
+// ExpandRecursive opens a folder and everything below it
+func (t *FsTree) ExpandRecursive(node *FsNode) error {
+	node = resolve(node)
+	if node == nil || node.Type != FolderNode {
+		return errors.New("only folder nodes can be expanded or collapsed")
+	}
+	node.Expanded = true
+	t.walkFolders(node, func(folder *FsNode) {
+		folder.Expanded = true
+	})
+	t.BuildLines()
+	return nil
+}
+
+// Reveal selects a path without reloading it, opening the folders above it.
+// False if it isn't in the tree or hidden by notes only mode.
+func (t *FsTree) Reveal(path string) bool {
+	node := t.findNodeByPath(t.viewRoot(), path)
+	if node == nil || !t.kindVisible(node) {
+		return false
+	}
+	return t.RestoreSelection(path)
+}
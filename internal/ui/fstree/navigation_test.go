@@ -0,0 +1,70 @@
+package fstree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tests parent, first/last, gg/G and bulk expand/collapse
+func TestNavigation(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// setup structure:
+	// root/
+	//   top.md
+	//   folder/
+	//     deep/
+	//       leaf.md
+	os.MkdirAll(filepath.Join(tmpDir, "folder", "deep"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "top.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "folder", "deep", "leaf.md"), []byte(""), 0644)
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	node := func(rel string) *FsNode {
+		return tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, rel))
+	}
+	top, folder, deep, leaf := node("top.md"), node("folder"), node(filepath.Join("folder", "deep")), node(filepath.Join("folder", "deep", "leaf.md"))
+
+	key := func(k string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		tree.Update(msg)
+	}
+
+	key("G")
+	if tree.SelectedNode != leaf {
+		t.Fatal("expected G to go to the last node")
+	}
+	tree.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if tree.SelectedNode != deep {
+		t.Error("expected backspace to go to the parent folder")
+	}
+	key("g")
+	if tree.SelectedNode != deep {
+		t.Error("expected a single g to do nothing yet")
+	}
+	key("g")
+	if tree.SelectedNode != top {
+		t.Error("expected gg to go to the first node")
+	}
+
+	tree.SelectedNode = leaf
+	tree.CollapseAll()
+	if folder.Expanded || deep.Expanded || tree.SelectedNode != folder {
+		t.Error("expected everything collapsed and the cursor on the top level folder")
+	}
+	if err := tree.ExpandRecursive(folder); err != nil || !folder.Expanded || !deep.Expanded {
+		t.Error("expected folder to be expanded all the way down")
+	}
+
+	tree.CollapseAll()
+	if !tree.Reveal(leaf.Path) || tree.SelectedNode != leaf || !deep.Expanded {
+		t.Error("expected reveal to open the folders above the note")
+	}
+}
@@ -0,0 +1,183 @@
+/*
+pinned notes and folders, drawn in their own section above the tree. each pin
+is a small proxy node pointing at the real node so it gets its own line and
+place in the flat list, which is all MoveUp/MoveDown need to walk through it.
+*/
+
+package fstree
+
+import (
+	"slices"
+	"strings"
+
+	"mend/styles"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const pinnedHeader = "Pinned"
+
+func (n *FsNode) isPin() bool {
+	return n != nil && n.pinTarget != nil
+}
+
+// resolve gives the real node behind a pin, the node itself otherwise
+func resolve(node *FsNode) *FsNode {
+	if node.isPin() {
+		return node.pinTarget
+	}
+	return node
+}
+
+func (t *FsTree) IsPinned(node *FsNode) bool {
+	return t.pinFor(resolve(node)) != nil
+}
+
+func (t *FsTree) pinFor(target *FsNode) *FsNode {
+	for _, pin := range t.pins {
+		if pin.pinTarget == target {
+			return pin
+		}
+	}
+	return nil
+}
+
+// TogglePin pins or unpins the node under the cursor
+func (t *FsTree) TogglePin() {
+	if t.SelectedNode == nil {
+		return
+	}
+	target := resolve(t.SelectedNode)
+	if pin := t.pinFor(target); pin != nil {
+		if t.SelectedNode == pin {
+			t.SelectedNode = target
+			t.oldSelected = target // same note, no need to reload it
+		}
+		if t.hoveredNode == pin {
+			t.hoveredNode = nil
+		}
+		t.pins = slices.DeleteFunc(t.pins, func(p *FsNode) bool { return p == pin })
+	} else {
+		t.pins = append(t.pins, &FsNode{Type: target.Type, Path: target.Path, pinTarget: target})
+	}
+	t.BuildLines()
+}
+
+// PinnedPaths are the pinned paths in display order
+func (t *FsTree) PinnedPaths() []string {
+	t.prunePins()
+	paths := make([]string, 0, len(t.pins))
+	for _, pin := range t.pins {
+		paths = append(paths, pin.pinTarget.Path)
+	}
+	return paths
+}
+
+// SetPins replaces the pins, paths not in the tree are dropped
+func (t *FsTree) SetPins(paths []string) {
+	t.pins = nil
+	for _, path := range paths {
+		if target := t.findNodeByPath(t.Root, path); target != nil && target != t.Root && t.pinFor(target) == nil {
+			t.pins = append(t.pins, &FsNode{Type: target.Type, Path: target.Path, pinTarget: target})
+		}
+	}
+	t.BuildLines()
+}
+
+// moves a pin up or down in the pinned section
+func (t *FsTree) movePin(pin *FsNode, delta int) {
+	idx := slices.Index(t.pins, pin)
+	target := idx + delta
+	if idx < 0 || target < 0 || target >= len(t.pins) {
+		return
+	}
+	t.pins[idx], t.pins[target] = t.pins[target], t.pins[idx]
+	t.BuildLines()
+}
+
+// drops pins whose node left the tree and keeps paths in sync after moves
+func (t *FsTree) prunePins() {
+	t.pins = slices.DeleteFunc(t.pins, func(pin *FsNode) bool {
+		if t.isAttached(pin.pinTarget) {
+			pin.Path = pin.pinTarget.Path
+			return false
+		}
+		if t.SelectedNode == pin {
+			t.SelectedNode = t.firstVisibleNode(t.viewRoot())
+		}
+		if t.hoveredNode == pin {
+			t.hoveredNode = nil
+		}
+		return true
+	})
+}
+
+// removed nodes keep their Parent, so check the children all the way up
+func (t *FsTree) isAttached(node *FsNode) bool {
+	for n := node; n != nil; n = n.Parent {
+		if n == t.Root {
+			return true
+		}
+		if n.Parent == nil || !slices.Contains(n.Parent.Children, n) {
+			return false
+		}
+	}
+	return false
+}
+
+// pins are hidden while filtering or showing tags and follow the notes only rule of their node
+func (t *FsTree) visiblePins() []*FsNode {
+	if t.filtering || t.tagRoot != nil {
+		return nil
+	}
+	pins := make([]*FsNode, 0, len(t.pins))
+	for _, pin := range t.pins {
+		if t.kindVisible(pin.pinTarget) {
+			pins = append(pins, pin)
+		}
+	}
+	return pins
+}
+
+// buildPinLines puts the header, the pins and a blank line before the tree,
+// returns the line the tree starts on
+func (t *FsTree) buildPinLines(flatTree *[]*FsNode) int {
+	t.prunePins()
+	pins := t.visiblePins()
+	if len(pins) == 0 {
+		return 0
+	}
+	line := 1 // header
+	for _, pin := range pins {
+		t.lines[line] = pin
+		pin.line = line
+		*flatTree = append(*flatTree, pin)
+		line++
+
+		w := 3 + len(pin.pinTarget.FileName())
+		if w > t.maxContentWidth {
+			t.maxContentWidth = w
+		}
+	}
+	return line + 1 // blank line after the pins
+}
+
+// renderPins has to produce exactly the lines buildPinLines accounted for
+func (t *FsTree) renderPins(builder *strings.Builder) {
+	pins := t.visiblePins()
+	if len(pins) == 0 {
+		return
+	}
+	builder.WriteString(lipgloss.NewStyle().Faint(true).Render(pinnedHeader) + "\n")
+	for _, pin := range pins {
+		fileName := pin.pinTarget.FileName()
+		if pin == t.SelectedNode {
+			fileName = lipgloss.NewStyle().Foreground(styles.Highlight).Bold(true).Render(fileName)
+		} else if pin == t.hoveredNode {
+			fileName = lipgloss.NewStyle().Foreground(styles.HoverHighlight).Render(fileName)
+		}
+		icon := lipgloss.NewStyle().Foreground(styles.Primary).Render(styles.PinIcon)
+		builder.WriteString(" " + icon + " " + fileName + "\n")
+	}
+	builder.WriteString("\n")
+}
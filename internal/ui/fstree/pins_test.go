@@ -0,0 +1,88 @@
+package fstree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tests pinning, walking through the pinned section and unpinning
+func TestPins(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.Mkdir(filepath.Join(tmpDir, "folder"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "folder", "b.md"), []byte(""), 0644)
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	b := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "folder", "b.md"))
+	tree.SelectedNode = b
+	tree.TogglePin()
+
+	if !tree.IsPinned(b) || len(tree.pins) != 1 {
+		t.Fatal("expected b.md to be pinned")
+	}
+	pin := tree.pins[0]
+	if pin.line != 1 || tree.treeStartLine != 3 {
+		t.Errorf("expected pin on line 1 and tree on line 3, got %d and %d", pin.line, tree.treeStartLine)
+	}
+
+	// the first tree node is reachable from the pin and back
+	first := tree.Root.Children[0]
+	tree.SelectedNode = pin
+	if err := tree.MoveDown(); err != nil || tree.SelectedNode != first {
+		t.Error("expected to move from the pin into the tree")
+	}
+	if err := tree.MoveUp(); err != nil || tree.SelectedNode != pin {
+		t.Error("expected to move from the tree back to the pin")
+	}
+
+	// toggling on the pin itself unpins and lands on the real node
+	tree.TogglePin()
+	if tree.IsPinned(b) || tree.SelectedNode != b {
+		t.Error("expected unpin to select the real node")
+	}
+	if tree.treeStartLine != 0 {
+		t.Error("expected the pinned section to disappear")
+	}
+}
+
+// tests pins following their node and restoring them from paths
+func TestPinsPruneAndRestore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "b.md"), []byte(""), 0644)
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	aPath := filepath.Join(tmpDir, "a.md")
+	bPath := filepath.Join(tmpDir, "b.md")
+	tree.SetPins([]string{bPath, filepath.Join(tmpDir, "missing.md"), aPath, bPath})
+
+	paths := tree.PinnedPaths()
+	if len(paths) != 2 || paths[0] != bPath || paths[1] != aPath {
+		t.Fatalf("expected pins [b a], got %v", paths)
+	}
+
+	// a pin goes away with its node
+	tree.SelectedNode = tree.pins[0]
+	if err := tree.DeleteNode(tree.findNodeByPath(tree.Root, bPath)); err != nil {
+		t.Fatal(err)
+	}
+	tree.BuildLines()
+	paths = tree.PinnedPaths()
+	if len(paths) != 1 || paths[0] != aPath {
+		t.Errorf("expected only a.md to stay pinned, got %v", paths)
+	}
+	if tree.SelectedNode == nil || tree.SelectedNode.isPin() && !tree.isAttached(tree.SelectedNode.pinTarget) {
+		t.Error("expected selection to move off the dropped pin")
+	}
+}
@@ -0,0 +1,304 @@
+/*
+multi selection in the tree. marks are separate from the cursor (SelectedNode),
+bulk actions work on the marked nodes or on the cursor when nothing is marked.
+*/
+
+package fstree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mend/internal/filesystem"
+	"mend/internal/tags"
+	"mend/utils"
+)
+
+// Move is a single path change made by the tree, for anything holding on to old paths
+type Move struct {
+	From string
+	To   string
+}
+
+func (t *FsTree) IsMarked(node *FsNode) bool {
+	return t.marked[node]
+}
+
+func (t *FsTree) ToggleMark(node *FsNode) {
+	if node == nil {
+		return
+	}
+	if t.marked == nil {
+		t.marked = make(map[*FsNode]bool)
+	}
+	if t.marked[node] {
+		delete(t.marked, node)
+	} else {
+		t.marked[node] = true
+	}
+	t.rangeAnchor = nil
+	t.BuildLines() // marker changes the width
+}
+
+func (t *FsTree) ClearMarks() {
+	t.marked = nil
+	t.rangeAnchor = nil
+	t.BuildLines()
+}
+
+// ExtendSelection moves the cursor and marks everything between where the
+// range started and the cursor, like shift+arrows in a file manager
+func (t *FsTree) ExtendSelection(delta int) error {
+	if t.SelectedNode == nil {
+		return errors.New("no node is currently selected")
+	}
+	if t.rangeAnchor == nil {
+		t.rangeAnchor = t.SelectedNode
+	}
+	if err := t.move(delta); err != nil {
+		return err
+	}
+
+	from, to := t.rangeAnchor, t.SelectedNode
+	if from.line > to.line {
+		from, to = to, from
+	}
+	t.marked = make(map[*FsNode]bool)
+	for n := from; n != nil; n = n.nextFlatNode {
+		t.marked[resolve(n)] = true
+		if n == to {
+			break
+		}
+	}
+	t.BuildLines()
+	return nil
+}
+
+// Selection is what bulk actions operate on: marked nodes in tree order, or
+// the cursor when nothing is marked. Nodes inside a marked folder are left
+// out as acting on the folder already covers them.
+func (t *FsTree) Selection() []*FsNode {
+	if len(t.marked) == 0 {
+		if t.SelectedNode == nil {
+			return nil
+		}
+		return []*FsNode{resolve(t.SelectedNode)}
+	}
+	nodes := make([]*FsNode, 0, len(t.marked))
+	var walk func(node *FsNode)
+	walk = func(node *FsNode) {
+		for _, child := range node.Children {
+			if t.marked[child] {
+				nodes = append(nodes, child)
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(t.Root)
+	return nodes
+}
+
+// NotePaths lists every note in the tree, whatever is shown right now
+func (t *FsTree) NotePaths() []string {
+	paths := make([]string, 0)
+	var walk func(node *FsNode)
+	walk = func(node *FsNode) {
+		for _, child := range node.Children {
+			if child.isNote() {
+				paths = append(paths, child.Path)
+			}
+			walk(child)
+		}
+	}
+	walk(t.Root)
+	return paths
+}
+
+// SelectedNotePaths expands the selection to every note in it, folders included
+func (t *FsTree) SelectedNotePaths() []string {
+	paths := make([]string, 0)
+	var collect func(node *FsNode)
+	collect = func(node *FsNode) {
+		if node.Type == FileNode {
+			if strings.HasSuffix(node.Path, ".md") {
+				paths = append(paths, node.Path)
+			}
+			return
+		}
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+	for _, node := range t.Selection() {
+		collect(node)
+	}
+	return paths
+}
+
+// DeleteSelection deletes everything selected once all of it can be. a node
+// that fails on disk doesn't stop the rest, it stays marked and its error comes
+// back with the others
+func (t *FsTree) DeleteSelection() error {
+	nodes := t.Selection()
+	for _, node := range nodes {
+		if err := t.checkDelete(node); err != nil {
+			return err
+		}
+	}
+	errs := make([]error, 0)
+	for _, node := range nodes {
+		if err := t.DeleteNode(node); err != nil { // unmarks it when it's gone
+			errs = append(errs, fmt.Errorf("%s: %w", t.RelPath(node.Path), err))
+		}
+	}
+	t.rangeAnchor = nil
+	t.BuildLines()
+	return errors.Join(errs...)
+}
+
+// MoveSelection moves everything selected into a folder given relative to the
+// workspace, see RelPath. nothing moves unless all of it can, a node that then
+// fails on disk stays marked and its error comes back with the others
+func (t *FsTree) MoveSelection(destRel string) error {
+	dest := t.Root
+	destRel = strings.Trim(destRel, "/")
+	if destRel != "" && destRel != "." {
+		dest = t.findNodeByPath(t.Root, t.AbsPath(destRel))
+	}
+	if dest == t.Root && t.IsMultiRoot() {
+		dest = nil // the virtual root is not a folder on disk
+	}
+	if dest == nil || dest.Type != FolderNode {
+		return errors.New("destination folder does not exist")
+	}
+
+	nodes := t.Selection()
+	names := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		if slices.Contains(t.roots, node) {
+			return errors.New("a workspace root cannot be moved")
+		}
+		if isAncestorOrSelf(node, dest) {
+			return errors.New("cannot move a folder into itself")
+		}
+		if node.Parent == dest {
+			continue
+		}
+		name := filepath.Base(node.Path)
+		if _, err := os.Lstat(filepath.Join(dest.Path, name)); err == nil || names[name] {
+			return fmt.Errorf("%s already exists in the destination", name)
+		}
+		names[name] = true
+	}
+
+	errs := make([]error, 0)
+	for _, node := range nodes {
+		if node.Parent == dest {
+			delete(t.marked, node)
+			continue
+		}
+		from := node.Path
+		to := filepath.Join(dest.Path, filepath.Base(node.Path))
+		if err := filesystem.MovePath(from, to); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.RelPath(from), err))
+			continue
+		}
+		node.Parent.Children = utils.RemoveFromSlice(node.Parent.Children, node)
+		setPathRecursive(node, to)
+		t.insertChild(dest, node)
+		t.pendingMoves = append(t.pendingMoves, Move{From: from, To: to})
+		t.changed = append(t.changed, from, to)
+		delete(t.marked, node)
+	}
+	dest.Expanded = true
+	t.rangeAnchor = nil
+	t.BuildLines()
+	return errors.Join(errs...)
+}
+
+// RenameNode gives a node a new name in the same folder. notes keep being
+// notes when the new name has no extension.
+func (t *FsTree) RenameNode(node *FsNode, name string) error {
+	node = resolve(node)
+	if node == nil || node.Parent == nil {
+		return errors.New("no node is currently selected")
+	}
+	if slices.Contains(t.roots, node) {
+		return errors.New("a workspace root cannot be renamed")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return errors.New("invalid name")
+	}
+	if node.isNote() && filepath.Ext(name) == "" {
+		name += ".md"
+	}
+
+	from := node.Path
+	to := filepath.Join(filepath.Dir(from), name)
+	if to == from {
+		return nil
+	}
+	if err := filesystem.MovePath(from, to); err != nil {
+		return err
+	}
+	node.Parent.Children = utils.RemoveFromSlice(node.Parent.Children, node)
+	setPathRecursive(node, to)
+	t.insertChild(node.Parent, node)
+	t.pendingMoves = append(t.pendingMoves, Move{From: from, To: to})
+	t.changed = append(t.changed, from, to)
+	t.BuildLines()
+	return nil
+}
+
+// TagSelection adds a tag to every note in the selection
+func (t *FsTree) TagSelection(tag string) error {
+	for _, path := range t.SelectedNotePaths() {
+		if err := tags.Add(path, tag); err != nil {
+			return err
+		}
+		t.changed = append(t.changed, path)
+	}
+	t.ClearMarks()
+	return nil
+}
+
+// takeMoves hands over the moves made since the last call
+func (t *FsTree) takeMoves() []Move {
+	moves := t.pendingMoves
+	t.pendingMoves = nil
+	return moves
+}
+
+// TakeChanged hands over the paths the tree changed on disk since the last
+// call, nothing when an action didn't get to change anything
+func (t *FsTree) TakeChanged() []string {
+	changed := t.changed
+	t.changed = nil
+	return changed
+}
+
+func setPathRecursive(node *FsNode, path string) {
+	old := node.Path
+	node.Path = path
+	for _, child := range node.Children {
+		setPathRecursive(child, filepath.Join(path, strings.TrimPrefix(child.Path, old+string(filepath.Separator))))
+	}
+}
+
+// drops marks on a node that is going away and everything below it
+func (t *FsTree) unmarkSubtree(node *FsNode) {
+	for marked := range t.marked {
+		if isAncestorOrSelf(node, marked) {
+			delete(t.marked, marked)
+		}
+	}
+	if t.rangeAnchor != nil && isAncestorOrSelf(node, t.rangeAnchor) {
+		t.rangeAnchor = nil
+	}
+}
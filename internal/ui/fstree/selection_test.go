@@ -0,0 +1,190 @@
+package fstree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// root with a.md, b.md, c.md and an empty dest/ folder
+func newSelectionTree(t *testing.T) (*FsTree, string) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	for _, name := range []string{"a.md", "b.md", "c.md"} {
+		os.WriteFile(filepath.Join(tmpDir, name), []byte("# "+name+"\n"), 0644)
+	}
+	os.Mkdir(filepath.Join(tmpDir, "dest"), 0755)
+	return NewFsTree(tmpDir, 0, nil), tmpDir
+}
+
+// tests marking single nodes and ranges
+func TestMarkAndExtendSelection(t *testing.T) {
+	tree, tmpDir := newSelectionTree(t)
+	a := tree.Root.Children[0]
+
+	// nothing marked, selection is the cursor
+	if sel := tree.Selection(); len(sel) != 1 || sel[0] != a {
+		t.Fatal("expected selection to fall back to cursor")
+	}
+
+	tree.ToggleMark(a)
+	if !tree.IsMarked(a) {
+		t.Error("expected node to be marked")
+	}
+	tree.ToggleMark(a)
+	if tree.IsMarked(a) {
+		t.Error("expected node to be unmarked")
+	}
+
+	// range from a down to c
+	tree.ExtendSelection(1)
+	tree.ExtendSelection(1)
+	sel := tree.Selection()
+	if len(sel) != 3 || filepath.Base(sel[2].Path) != "c.md" {
+		t.Errorf("expected a..c to be selected, got %d nodes", len(sel))
+	}
+	// shrinking the range back
+	tree.ExtendSelection(-1)
+	if len(tree.Selection()) != 2 {
+		t.Errorf("expected 2 nodes after shrinking, got %d", len(tree.Selection()))
+	}
+
+	paths := tree.SelectedNotePaths()
+	if len(paths) != 2 || paths[0] != filepath.Join(tmpDir, "a.md") {
+		t.Errorf("unexpected note paths %v", paths)
+	}
+}
+
+// tests bulk move and delete
+func TestBulkMoveDelete(t *testing.T) {
+	tree, tmpDir := newSelectionTree(t)
+	tree.ToggleMark(tree.Root.Children[0])
+	tree.ToggleMark(tree.Root.Children[1])
+
+	if err := tree.MoveSelection("dest"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "dest", "a.md")); err != nil {
+		t.Error("a.md not moved on fs")
+	}
+	dest := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "dest"))
+	if len(dest.Children) != 2 {
+		t.Errorf("expected 2 nodes in dest, got %d", len(dest.Children))
+	}
+	if moves := tree.takeMoves(); len(moves) != 2 {
+		t.Errorf("expected 2 moves reported, got %d", len(moves))
+	}
+	if changed := tree.TakeChanged(); len(changed) != 4 {
+		t.Errorf("expected both ends of 2 moves as changed, got %v", changed)
+	}
+
+	// mark the folder and the file left at the root
+	tree.ToggleMark(dest)
+	tree.ToggleMark(tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "c.md")))
+	if err := tree.DeleteSelection(); err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Root.Children) != 0 {
+		t.Errorf("expected empty tree, got %d children", len(tree.Root.Children))
+	}
+	if changed := tree.TakeChanged(); len(changed) != 2 {
+		t.Errorf("expected the 2 deleted paths as changed, got %v", changed)
+	}
+	if changed := tree.TakeChanged(); len(changed) != 0 {
+		t.Errorf("expected nothing changed after taking, got %v", changed)
+	}
+}
+
+// tests that a move checks everything first and a node failing on disk
+// doesn't stop the others, only what moved is unmarked
+func TestMoveSelectionPartial(t *testing.T) {
+	tree, tmpDir := newSelectionTree(t)
+	a := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "a.md"))
+	b := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "b.md"))
+	c := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "c.md"))
+
+	// b.md is already in dest, nothing moves
+	os.WriteFile(filepath.Join(tmpDir, "dest", "b.md"), []byte(""), 0644)
+	tree.ToggleMark(a)
+	tree.ToggleMark(b)
+	if err := tree.MoveSelection("dest"); err == nil {
+		t.Fatal("expected the name clash to be reported")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "a.md")); err != nil {
+		t.Error("expected a.md to stay when the move can't be done whole")
+	}
+	if !tree.IsMarked(a) || !tree.IsMarked(b) {
+		t.Error("expected the marks kept")
+	}
+
+	// c.md went away behind the tree's back, a.md still moves
+	tree.ToggleMark(b)
+	tree.ToggleMark(c)
+	os.Remove(filepath.Join(tmpDir, "c.md"))
+	err := tree.MoveSelection("dest")
+	if err == nil || !strings.Contains(err.Error(), "c.md") {
+		t.Errorf("expected the error of c.md, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "dest", "a.md")); err != nil {
+		t.Error("expected a.md moved")
+	}
+	if tree.IsMarked(a) || !tree.IsMarked(c) {
+		t.Error("expected only the moved node unmarked")
+	}
+}
+
+// tests tagging every note in the selection
+func TestTagSelection(t *testing.T) {
+	tree, tmpDir := newSelectionTree(t)
+	tree.ToggleMark(tree.Root.Children[0])
+	tree.ToggleMark(tree.Root.Children[2])
+
+	if err := tree.TagSelection("#review"); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"a.md": true, "b.md": false, "c.md": true} {
+		data, _ := os.ReadFile(filepath.Join(tmpDir, name))
+		if got := strings.Contains(string(data), "#review"); got != want {
+			t.Errorf("%s tagged = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// tests renaming a note and a folder in place
+func TestRenameNode(t *testing.T) {
+	tree, tmpDir := newSelectionTree(t)
+	a := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "a.md"))
+
+	if err := tree.RenameNode(a, "z"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "z.md")); err != nil {
+		t.Error("expected .md to be added to the new name")
+	}
+	if a.Path != filepath.Join(tmpDir, "z.md") || tree.Root.Children[2] != a {
+		t.Errorf("expected the node renamed and sorted after c.md, got %s", a.Path)
+	}
+
+	if err := tree.RenameNode(a, "sub/x.md"); err == nil {
+		t.Error("expected an error for a name with a separator")
+	}
+	if err := tree.RenameNode(tree.Root, "other"); err == nil {
+		t.Error("expected an error renaming the root")
+	}
+
+	dest := tree.findNodeByPath(tree.Root, filepath.Join(tmpDir, "dest"))
+	if err := tree.RenameNode(dest, "archive"); err != nil {
+		t.Fatal(err)
+	}
+	if dest.Path != filepath.Join(tmpDir, "archive") {
+		t.Errorf("expected the folder renamed without an extension, got %s", dest.Path)
+	}
+	if moves := tree.takeMoves(); len(moves) != 2 || moves[0].To != filepath.Join(tmpDir, "z.md") {
+		t.Errorf("expected both renames reported, got %+v", moves)
+	}
+}
@@ -0,0 +1,246 @@
+/*
+ordering of children in the tree. files always come before folders (the
+renderer leaves a gap before top level folders), the sort mode decides the
+order within each of the two groups. the same ordering is used when the tree
+is built and whenever a node is inserted so things don't drift after edits.
+*/
+
+package fstree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"mend/internal/filesystem"
+	"mend/internal/review"
+	"mend/internal/session"
+	"mend/utils"
+)
+
+type SortMode int
+
+const (
+	SortName SortMode = iota
+	SortModified
+	SortCreated
+	SortSections
+	SortManual
+	sortModeCount // keep last, used to cycle
+)
+
+var sortModeNames = map[SortMode]string{
+	SortName:     "name",
+	SortModified: "modified",
+	SortCreated:  "created",
+	SortSections: "sections",
+	SortManual:   "manual",
+}
+
+func (s SortMode) String() string {
+	return sortModeNames[s]
+}
+
+func ParseSortMode(name string) (SortMode, bool) {
+	for mode, n := range sortModeNames {
+		if n == name {
+			return mode, true
+		}
+	}
+	return SortName, false
+}
+
+// everything a comparison might need, looked up once per sort instead of per compare
+type sortKey struct {
+	modified time.Time
+	created  time.Time
+	sections int
+}
+
+func (t *FsTree) SortMode() SortMode {
+	return t.sortMode
+}
+
+func (t *FsTree) SetSortMode(mode SortMode) {
+	t.sortMode = mode
+	t.sortRecursive(t.Root)
+	t.BuildLines()
+}
+
+func (t *FsTree) CycleSortMode() {
+	t.SetSortMode((t.sortMode + 1) % sortModeCount)
+}
+
+// insertChild adds a node to a folder at the position the sort mode puts it
+func (t *FsTree) insertChild(folder, node *FsNode) {
+	node.Parent = folder
+	folder.Children = append(folder.Children, node)
+	if node.Type == FolderNode {
+		t.sortRecursive(node)
+	}
+	t.sortChildren(folder)
+}
+
+func (t *FsTree) sortRecursive(node *FsNode) {
+	t.sortChildren(node)
+	for _, child := range node.Children {
+		if child.Type == FolderNode {
+			t.sortRecursive(child)
+		}
+	}
+}
+
+func (t *FsTree) sortChildren(folder *FsNode) {
+	if folder == t.Root && t.IsMultiRoot() {
+		return // roots stay in the order they were opened in
+	}
+	keys := make(map[*FsNode]sortKey, len(folder.Children))
+	if t.sortMode == SortModified || t.sortMode == SortCreated || t.sortMode == SortSections {
+		for _, child := range folder.Children {
+			keys[child] = t.sortKeyOf(child)
+		}
+	}
+
+	var manual map[string]int
+	if t.sortMode == SortManual {
+		manual = make(map[string]int)
+		for i, name := range t.orders[t.rootOf(folder)][t.orderKey(folder)] {
+			manual[name] = i
+		}
+	}
+
+	slices.SortStableFunc(folder.Children, func(a, b *FsNode) int {
+		// files first, folders after
+		if a.Type != b.Type {
+			if a.Type == FileNode {
+				return -1
+			}
+			return 1
+		}
+		ka, kb := keys[a], keys[b]
+		switch t.sortMode {
+		case SortModified:
+			if c := kb.modified.Compare(ka.modified); c != 0 {
+				return c // newest first
+			}
+		case SortCreated:
+			if c := kb.created.Compare(ka.created); c != 0 {
+				return c
+			}
+		case SortSections:
+			if c := kb.sections - ka.sections; c != 0 {
+				return c // most cards first
+			}
+		case SortManual:
+			ia, aOk := manual[filepath.Base(a.Path)]
+			ib, bOk := manual[filepath.Base(b.Path)]
+			switch {
+			case aOk && bOk:
+				return ia - ib
+			case aOk:
+				return -1
+			case bOk:
+				return 1
+			}
+		}
+		// everything falls back to names for ties and unknowns
+		return utils.NaturalCompare(filepath.Base(a.Path), filepath.Base(b.Path))
+	})
+}
+
+func (t *FsTree) sortKeyOf(node *FsNode) sortKey {
+	key := sortKey{}
+	if info, err := os.Stat(node.Path); err == nil {
+		key.modified = info.ModTime()
+		key.created = filesystem.CreatedTime(node.Path, info)
+	}
+	if t.sortMode == SortSections {
+		key.sections = sectionCount(node)
+	}
+	return key
+}
+
+// folders have the cards of everything in them
+func sectionCount(node *FsNode) int {
+	if node.Type == FileNode {
+		return review.CardCount(node.Path)
+	}
+	total := 0
+	for _, child := range node.Children {
+		total += sectionCount(child)
+	}
+	return total
+}
+
+// folder path relative to its own root
+func (t *FsTree) orderKey(folder *FsNode) string {
+	return session.Rel(t.rootOf(folder).Path, folder.Path)
+}
+
+// MoveInManualOrder moves the selected node up (-1) or down (1) among its
+// siblings of the same type and switches to manual ordering, starting from
+// whatever order was on screen. The order is saved next to the session.
+func (t *FsTree) MoveInManualOrder(delta int) error {
+	node := t.SelectedNode
+	if node.isPin() {
+		t.movePin(node, delta)
+		return nil
+	}
+	if node == nil || node.Parent == nil {
+		return errors.New("no node is currently selected")
+	}
+	if slices.Contains(t.roots, node) {
+		return nil // roots stay in the order they were opened in
+	}
+	siblings := node.Parent.Children
+	idx := slices.Index(siblings, node)
+	target := idx + delta
+	if target < 0 || target >= len(siblings) || siblings[target].Type != node.Type {
+		return nil // already at the edge of its group
+	}
+	if t.orders == nil {
+		t.orders = make(map[*FsNode]session.Order)
+	}
+	for _, root := range t.roots {
+		if t.orders[root] == nil {
+			t.orders[root] = make(session.Order)
+		}
+	}
+	if t.sortMode != SortManual {
+		// freeze every folder as it currently looks, else switching modes
+		// would reshuffle folders the user never touched
+		for _, root := range t.roots {
+			t.snapshotOrder(root)
+		}
+		t.sortMode = SortManual
+	}
+
+	siblings[idx], siblings[target] = siblings[target], siblings[idx]
+	t.orders[t.rootOf(node)][t.orderKey(node.Parent)] = childNames(node.Parent)
+	t.BuildLines()
+	for _, root := range t.roots {
+		if err := session.SaveOrder(root.Path, t.orders[root]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *FsTree) snapshotOrder(folder *FsNode) {
+	t.orders[t.rootOf(folder)][t.orderKey(folder)] = childNames(folder)
+	for _, child := range folder.Children {
+		if child.Type == FolderNode {
+			t.snapshotOrder(child)
+		}
+	}
+}
+
+func childNames(folder *FsNode) []string {
+	names := make([]string, 0, len(folder.Children))
+	for _, child := range folder.Children {
+		names = append(names, filepath.Base(child.Path))
+	}
+	return names
+}
@@ -0,0 +1,106 @@
+package fstree
+
+import (
+	"mend/internal/session"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func childBaseNames(node *FsNode) []string {
+	names := make([]string, 0)
+	for _, child := range node.Children {
+		names = append(names, filepath.Base(child.Path))
+	}
+	return names
+}
+
+func assertNames(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+// tests that build and insert use the same ordering
+func TestSortNameOnBuildAndInsert(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "note 10.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "note 2.md"), []byte(""), 0644)
+	os.Mkdir(filepath.Join(tmpDir, "b"), 0755)
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	assertNames(t, childBaseNames(tree.Root), "note 2.md", "note 10.md", "b")
+
+	if err := tree.CreateNode(tree.Root, "note 3", FileNode); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.CreateNode(tree.Root, "a", FolderNode); err != nil {
+		t.Fatal(err)
+	}
+	assertNames(t, childBaseNames(tree.Root), "note 2.md", "note 3.md", "note 10.md", "a", "b")
+}
+
+// tests modified time ordering puts the newest first
+func TestSortModified(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	old := filepath.Join(tmpDir, "a.md")
+	recent := filepath.Join(tmpDir, "b.md")
+	os.WriteFile(old, []byte(""), 0644)
+	os.WriteFile(recent, []byte(""), 0644)
+	os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	tree.SetSortMode(SortModified)
+	assertNames(t, childBaseNames(tree.Root), "b.md", "a.md")
+}
+
+// tests manual reordering and that it is persisted
+func TestMoveInManualOrder(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, name := range []string{"a.md", "b.md", "c.md"} {
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(""), 0644)
+	}
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	tree.SelectByPath(filepath.Join(tmpDir, "c.md"))
+	if err := tree.MoveInManualOrder(-1); err != nil {
+		t.Fatal(err)
+	}
+	if tree.SortMode() != SortManual {
+		t.Error("expected tree to switch to manual order")
+	}
+	assertNames(t, childBaseNames(tree.Root), "a.md", "c.md", "b.md")
+
+	order, err := session.LoadOrder(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNames(t, order["."], "a.md", "c.md", "b.md")
+
+	// a fresh tree in manual mode picks the saved order up
+	reloaded := NewFsTree(tmpDir, 0, nil)
+	reloaded.SetSortMode(SortManual)
+	assertNames(t, childBaseNames(reloaded.Root), "a.md", "c.md", "b.md")
+}
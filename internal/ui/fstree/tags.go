@@ -0,0 +1,191 @@
+/*
+tags view: the same tree but folders are tags and files the notes carrying them.
+nested tags (lang/go) become nested folders. it is built from the file tree on
+the side and only swapped in for rendering and navigation, everything that
+changes files keeps working on Root.
+*/
+package fstree
+
+import (
+	"slices"
+	"strings"
+
+	"mend/internal/tags"
+)
+
+// tag folders get paths below this so they never collide with real ones
+const tagPathPrefix = "#"
+
+// what is shown and navigated, the tags tree while it's on
+func (t *FsTree) viewRoot() *FsNode {
+	if t.tagRoot != nil {
+		return t.tagRoot
+	}
+	return t.Root
+}
+
+func (t *FsTree) IsTagsMode() bool {
+	return t.tagRoot != nil
+}
+
+func isTagFolder(node *FsNode) bool {
+	return node.Type == FolderNode && strings.HasPrefix(node.Path, tagPathPrefix)
+}
+
+// ToggleTagsMode switches between files and tags, the selected note stays selected
+// if it can be found in the other view
+func (t *FsTree) ToggleTagsMode() {
+	if t.filtering {
+		t.EndFilter(false)
+	}
+	selected := ""
+	if t.SelectedNode != nil && t.SelectedNode.Type == FileNode {
+		selected = resolve(t.SelectedNode).Path
+	}
+	t.ClearMarks()
+	t.hoveredNode = nil
+
+	if t.tagRoot != nil {
+		t.tagRoot = nil
+		t.SelectedNode = t.fileSelected
+		t.fileSelected = nil
+		if t.SelectedNode == nil || !t.isAttached(resolve(t.SelectedNode)) {
+			t.SelectedNode = t.firstVisibleNode(t.Root)
+		}
+	} else {
+		t.fileSelected = t.SelectedNode
+		t.tagRoot = t.buildTagTree()
+		t.SelectedNode = t.firstVisibleNode(t.tagRoot)
+	}
+	if selected == "" || !t.SelectByPath(selected) {
+		t.BuildLines()
+	}
+	t.oldSelected = t.SelectedNode // same note, nothing to load
+	t.viewStart, t.viewEnd = t.getViewportBounds()
+}
+
+// RefreshTags rereads the tags after notes changed, no-op outside the tags view
+func (t *FsTree) RefreshTags() {
+	if t.tagRoot == nil {
+		return
+	}
+	var selectedPath, selectedParent string
+	if t.SelectedNode != nil {
+		selectedPath = t.SelectedNode.Path
+		if t.SelectedNode.Parent != nil {
+			selectedParent = t.SelectedNode.Parent.Path
+		}
+	}
+	t.hoveredNode = nil
+	t.tagRoot = t.buildTagTree()
+
+	// same note under the same tag, else wherever it went, else the top
+	t.SelectedNode = nil
+	if parent := t.findNodeByPath(t.tagRoot, selectedParent); parent != nil && isTagFolder(parent) {
+		for _, child := range parent.Children {
+			if child.Path == selectedPath {
+				t.SelectedNode = child
+				break
+			}
+		}
+	}
+	if t.SelectedNode == nil {
+		t.SelectedNode = t.findNodeByPath(t.tagRoot, selectedPath)
+	}
+	if t.SelectedNode == nil {
+		t.SelectedNode = t.firstVisibleNode(t.tagRoot)
+	}
+	t.oldSelected = t.SelectedNode
+	if t.filtering {
+		t.applyFilter()
+		return
+	}
+	t.BuildLines()
+}
+
+// buildTagTree groups every note in the file tree by its tags, folders keep
+// the expansion they had in the previous tags tree
+func (t *FsTree) buildTagTree() *FsNode {
+	expanded := make(map[string]bool)
+	if t.tagRoot != nil {
+		t.walkFolders(t.tagRoot, func(folder *FsNode) {
+			expanded[folder.Path] = folder.Expanded
+		})
+	}
+
+	root := &FsNode{Type: FolderNode, Path: tagPathPrefix, Expanded: true, Children: make([]*FsNode, 0)}
+	folders := map[string]*FsNode{"": root}
+	var folderFor func(tag string) *FsNode
+	folderFor = func(tag string) *FsNode {
+		if folder, ok := folders[tag]; ok {
+			return folder
+		}
+		parent := root
+		if i := strings.LastIndex(tag, "/"); i >= 0 {
+			parent = folderFor(tag[:i])
+		}
+		path := tagPathPrefix + "/" + tag
+		open, seen := expanded[path]
+		folder := &FsNode{
+			Type:     FolderNode,
+			Path:     path,
+			Parent:   parent,
+			Expanded: open || !seen,
+			Children: make([]*FsNode, 0),
+		}
+		parent.Children = append(parent.Children, folder)
+		folders[tag] = folder
+		return folder
+	}
+
+	var walk func(node *FsNode)
+	walk = func(node *FsNode) {
+		for _, child := range node.Children {
+			if child.Type == FolderNode {
+				walk(child)
+				continue
+			}
+			if !child.isNote() {
+				continue
+			}
+			for _, tag := range tags.Read(child.Path) {
+				folder := folderFor(strings.Trim(tag, "/"))
+				folder.Children = append(folder.Children, &FsNode{
+					Type:    FileNode,
+					Path:    child.Path,
+					Parent:  folder,
+					Symlink: child.Symlink,
+				})
+			}
+		}
+	}
+	walk(t.Root)
+
+	sortTagTree(root)
+	return root
+}
+
+// sub tags first, then notes, both by name
+func sortTagTree(node *FsNode) {
+	slices.SortStableFunc(node.Children, func(a, b *FsNode) int {
+		if a.Type != b.Type {
+			return int(b.Type) - int(a.Type)
+		}
+		return strings.Compare(strings.ToLower(a.FileName()), strings.ToLower(b.FileName()))
+	})
+	for _, child := range node.Children {
+		if child.Type == FolderNode {
+			sortTagTree(child)
+		}
+	}
+}
+
+// keys that change files or the layout of the file tree, they make no sense on tags
+func editsTree(key string) bool {
+	switch key {
+	case "n", "N", "C", "D", "delete", "x", "p", "shift+up", "shift+down", "M", "m", "f2",
+		"S", "alt+up", "alt+w", "alt+down", "alt+s":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package fstree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tests grouping by nested tags, selecting across views and refreshing after edits
+func TestTagsMode(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// setup structure:
+	// root/
+	//   a.md      tags: [lang/go], #todo
+	//   b.md      #lang
+	//   plain.md
+	//   img.png
+	os.WriteFile(filepath.Join(tmpDir, "a.md"), []byte("---\ntags: [lang/go]\n---\n# a\n#todo\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "b.md"), []byte("about #lang\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "plain.md"), []byte("nothing\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "img.png"), []byte(""), 0644)
+	aPath := filepath.Join(tmpDir, "a.md")
+
+	tree := NewFsTree(tmpDir, 0, nil)
+	tree.SelectByPath(aPath)
+	fileNode := tree.SelectedNode
+
+	tree.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if !tree.IsTagsMode() {
+		t.Fatal("expected t to switch to the tags view")
+	}
+
+	// lang { go { a }, b }, todo { a }
+	root := tree.viewRoot()
+	if len(root.Children) != 2 || root.Children[0].FileName() != "lang" || root.Children[1].FileName() != "todo" {
+		t.Fatalf("expected tags lang and todo at the top, got %d", len(root.Children))
+	}
+	lang := root.Children[0]
+	if len(lang.Children) != 2 || lang.Children[0].FileName() != "go" || lang.Children[1].FileName() != "b" {
+		t.Fatal("expected the nested tag before the notes under lang")
+	}
+	if goTag := lang.Children[0]; len(goTag.Children) != 1 || goTag.Children[0].Path != aPath {
+		t.Error("expected a.md under lang/go")
+	}
+	if tree.SelectedNode == nil || tree.SelectedNode.Path != aPath || tree.SelectedNode == fileNode {
+		t.Error("expected the selected note to stay selected in the tags view")
+	}
+
+	// selecting a note under a tag opens the real file
+	todo := root.Children[1]
+	tree.SelectedNode = todo
+	tree.BuildLines()
+	_, cmd := tree.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if cmd == nil {
+		t.Fatal("expected a selection message")
+	}
+	if sel, ok := cmd().(NodeSelectedMsg); !ok || sel.Path != aPath {
+		t.Error("expected selecting a tagged note to open it")
+	}
+
+	// editing keys are refused
+	tree.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	if tree.ErrMsg == "" {
+		t.Error("expected duplicate to be refused in the tags view")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "a copy.md")); err == nil {
+		t.Error("expected no copy to be made")
+	}
+
+	// a new tag shows up after a refresh, expansion is kept
+	lang.Expanded = false
+	later := time.Now().Add(time.Second)
+	os.WriteFile(filepath.Join(tmpDir, "plain.md"), []byte("now #idea\n"), 0644)
+	os.Chtimes(filepath.Join(tmpDir, "plain.md"), later, later)
+	tree.RefreshTags()
+	root = tree.viewRoot()
+	if len(root.Children) != 3 || root.Children[0].FileName() != "idea" {
+		t.Fatal("expected the new tag after a refresh")
+	}
+	if root.Children[1].Expanded {
+		t.Error("expected collapsed tags to stay collapsed")
+	}
+	if tree.SelectedNode == nil || tree.SelectedNode.Path != aPath || tree.SelectedNode.Parent.FileName() != "todo" {
+		t.Error("expected the selection to stay on the same note under the same tag")
+	}
+
+	tree.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if tree.IsTagsMode() || tree.SelectedNode != fileNode {
+		t.Error("expected t to go back to the files with the note selected")
+	}
+}
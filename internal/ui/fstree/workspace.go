@@ -0,0 +1,140 @@
+/*
+several roots in one tree. with more than one root, Root is a virtual node
+without a path whose children are the root folders, so they show up as top
+level folders and everything that walks from Root covers all of them. each
+root keeps its own manual order, ignore rules and relative paths.
+*/
+
+package fstree
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mend/internal/ignore"
+	"mend/internal/session"
+)
+
+// NewWorkspaceTree is NewFsTree for several roots, shown in the given order
+func NewWorkspaceTree(rootPaths []string, startOffset int, matcher *ignore.Matcher) *FsTree {
+	tree := &FsTree{
+		startOffset: startOffset,
+		orders:      make(map[*FsNode]session.Order),
+		ignore:      matcher,
+	}
+	for _, rootPath := range rootPaths {
+		root := &FsNode{
+			Type:     FolderNode,
+			Path:     rootPath,
+			Children: make([]*FsNode, 0),
+			Expanded: true,
+		}
+		WalkFileSystemAndBuildTree(rootPath, root, matcher)
+		order, _ := session.LoadOrder(rootPath) // unreadable order just means name order
+		tree.orders[root] = order
+		tree.roots = append(tree.roots, root)
+	}
+	tree.rootNames = session.RootNames(rootPaths)
+
+	if len(tree.roots) == 1 {
+		tree.Root = tree.roots[0]
+	} else {
+		tree.Root = &FsNode{Type: FolderNode, Expanded: true}
+		for _, root := range tree.roots {
+			root.Parent = tree.Root
+			tree.Root.Children = append(tree.Root.Children, root)
+		}
+	}
+
+	tree.sortRecursive(tree.Root)
+	if len(tree.Root.Children) > 0 {
+		tree.SelectedNode = tree.Root.Children[0]
+	}
+	tree.BuildLines()
+	return tree
+}
+
+func (t *FsTree) IsMultiRoot() bool {
+	return len(t.roots) > 1
+}
+
+// RootPaths are the workspace roots in display order
+func (t *FsTree) RootPaths() []string {
+	paths := make([]string, 0, len(t.roots))
+	for _, root := range t.roots {
+		paths = append(paths, root.Path)
+	}
+	return paths
+}
+
+// roots show the name RelPath gives them, two roots called notes stay apart
+func (t *FsTree) displayName(node *FsNode) string {
+	if t.IsMultiRoot() {
+		if i := slices.Index(t.roots, node); i >= 0 {
+			return t.rootNames[i]
+		}
+	}
+	return node.FileName()
+}
+
+// rootOf is the workspace root a node lives in, nil for the virtual root
+func (t *FsTree) rootOf(node *FsNode) *FsNode {
+	for n := resolve(node); n != nil; n = n.Parent {
+		if slices.Contains(t.roots, n) {
+			return n
+		}
+	}
+	return nil
+}
+
+// root new top level folders go into, the one the cursor is in
+func (t *FsTree) currentRoot() *FsNode {
+	if root := t.rootOf(t.SelectedNode); root != nil {
+		return root
+	}
+	return t.roots[0]
+}
+
+// RelPath makes a path relative to the workspace. With several roots it starts
+// with the name of the root, e.g. "shared/ideas/a.md" or "work/notes/a.md"
+// when another root is called notes too.
+func (t *FsTree) RelPath(path string) string {
+	if !t.IsMultiRoot() {
+		return session.Rel(t.Root.Path, path)
+	}
+	for i, root := range t.roots {
+		rel, err := filepath.Rel(root.Path, path)
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join(t.rootNames[i], rel)
+		}
+	}
+	return path
+}
+
+// AbsPath is the reverse of RelPath
+func (t *FsTree) AbsPath(rel string) string {
+	if !t.IsMultiRoot() {
+		return session.Abs(t.Root.Path, rel)
+	}
+	if rel == "" || filepath.IsAbs(rel) {
+		return rel
+	}
+	// a name can have slashes itself, the longest one that fits wins
+	rel = filepath.ToSlash(rel)
+	match, matchLen := -1, -1
+	for i, name := range t.rootNames {
+		if (rel == name || strings.HasPrefix(rel, name+"/")) && len(name) > matchLen {
+			match, matchLen = i, len(name)
+		}
+	}
+	if match < 0 {
+		return filepath.FromSlash(rel)
+	}
+	return filepath.Join(t.roots[match].Path, filepath.FromSlash(strings.TrimPrefix(rel[matchLen:], "/")))
+}
+
+// RootNames are the names RelPath starts with, in display order
+func (t *FsTree) RootNames() []string {
+	return t.rootNames
+}
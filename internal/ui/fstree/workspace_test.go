@@ -0,0 +1,101 @@
+package fstree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mend/internal/filesystem"
+)
+
+// tests several roots side by side in one tree
+func TestWorkspaceTree(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// setup structure:
+	// tmp/
+	//   shared/
+	//     b.md
+	//   personal/
+	//     ideas/
+	//       a.md
+	shared := filepath.Join(tmpDir, "shared")
+	personal := filepath.Join(tmpDir, "personal")
+	os.Mkdir(shared, 0755)
+	os.MkdirAll(filepath.Join(personal, "ideas"), 0755)
+	os.WriteFile(filepath.Join(shared, "b.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(personal, "ideas", "a.md"), []byte(""), 0644)
+
+	tree := NewWorkspaceTree([]string{shared, personal}, 0, nil)
+	if !tree.IsMultiRoot() || len(tree.Root.Children) != 2 {
+		t.Fatal("expected both roots as top level entries")
+	}
+	if tree.Root.Children[0].Path != shared || tree.Root.Children[1].Path != personal {
+		t.Error("expected roots in the order they were given, not sorted")
+	}
+
+	// paths are relative to their own root, prefixed with its name
+	aPath := filepath.Join(personal, "ideas", "a.md")
+	rel := tree.RelPath(aPath)
+	if rel != filepath.Join("personal", "ideas", "a.md") {
+		t.Errorf("RelPath() = %s", rel)
+	}
+	if tree.AbsPath(rel) != aPath {
+		t.Errorf("AbsPath() = %s, want %s", tree.AbsPath(rel), aPath)
+	}
+
+	// roots themselves can't be deleted
+	if err := tree.DeleteNode(tree.Root.Children[0]); err == nil {
+		t.Error("expected deleting a root to fail")
+	}
+
+	// moving across roots works with the root's name in the destination
+	tree.SelectedNode = tree.findNodeByPath(tree.Root, filepath.Join(shared, "b.md"))
+	if err := tree.MoveSelection("personal/ideas"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(personal, "ideas", "b.md")); err != nil {
+		t.Error("expected b.md to move into the other root")
+	}
+
+	// external changes land in the right root
+	cPath := filepath.Join(shared, "c.md")
+	os.WriteFile(cPath, []byte(""), 0644)
+	if !tree.ApplyFsEvent(filesystem.Event{Op: filesystem.EventCreate, Path: cPath}) {
+		t.Fatal("expected the create to apply")
+	}
+	if node := tree.findNodeByPath(tree.Root, cPath); node == nil || node.Parent != tree.Root.Children[0] {
+		t.Error("expected c.md under the shared root")
+	}
+
+}
+
+// tests roots with the same folder name, each keeps its own relative paths
+func TestWorkspaceSameNames(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_fstree_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	work := filepath.Join(tmpDir, "work", "notes")
+	home := filepath.Join(tmpDir, "home", "notes")
+	os.MkdirAll(work, 0755)
+	os.MkdirAll(home, 0755)
+	os.WriteFile(filepath.Join(work, "a.md"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(home, "a.md"), []byte(""), 0644)
+
+	tree := NewWorkspaceTree([]string{work, home}, 0, nil)
+	for _, path := range []string{filepath.Join(work, "a.md"), filepath.Join(home, "a.md")} {
+		if got := tree.AbsPath(tree.RelPath(path)); got != path {
+			t.Errorf("AbsPath(RelPath(%s)) = %s", path, got)
+		}
+	}
+	if rel := tree.RelPath(filepath.Join(home, "a.md")); rel != filepath.Join("home", "notes", "a.md") {
+		t.Errorf("RelPath() = %s", rel)
+	}
+}
@@ -0,0 +1,227 @@
+/*
+autosave while editing. after a pause in typing, and every so often while
+typing without one, what the editor has is kept as a draft in .mend/recovery
+or, with autosave_to_note, written to the note itself. a draft left behind by
+a session that ended early is offered back the next time its note is opened.
+*/
+
+package note
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"mend/internal/filesystem"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// AutosaveMsg comes when it may be time to keep what the editor has
+type AutosaveMsg struct {
+	Path     string
+	token    int // the edit an idle save was waiting after
+	session  int // the editing session it belongs to
+	periodic bool
+}
+
+// AutosavedMsg says how an autosave went
+type AutosavedMsg struct {
+	Path    string
+	Content string
+	Direct  bool // the note itself was written, not a draft
+	Err     error
+	stamp   fileStamp
+	value   string // what the editor had, the new baseline once written to the note
+}
+
+type autosaveState struct {
+	idle     time.Duration // 0 turns autosave off
+	interval time.Duration
+	direct   bool
+	token    int       // bumped on every edit, an idle save only happens for the last one
+	session  int       // bumped whenever editing starts, older ticks are dropped
+	base     string    // the note when editing started
+	last     string    // what was kept last
+	wrote    bool      // the note itself was written during this session
+	drafted  bool      // there is a draft to drop once the note is saved
+	at       time.Time // when something was kept last
+	err      error
+}
+
+// SetAutosave turns autosave on after idle without typing and every interval
+// while typing, direct writes the note instead of a draft
+func (m *NoteView) SetAutosave(idle, interval time.Duration, direct bool) {
+	m.autosave.idle, m.autosave.interval, m.autosave.direct = idle, interval, direct
+}
+
+func (m *NoteView) startAutosave() tea.Cmd {
+	m.autosave.session++
+	m.autosave.base = m.rawContent
+	m.autosave.last = m.rawContent
+	m.autosave.wrote = false
+	m.autosave.drafted = false
+	m.autosave.at = time.Time{}
+	m.autosave.err = nil
+	return m.schedulePeriodic()
+}
+
+func (m *NoteView) scheduleIdle() tea.Cmd {
+	if m.autosave.idle <= 0 {
+		return nil
+	}
+	m.autosave.token++
+	msg := AutosaveMsg{Path: m.Path, token: m.autosave.token, session: m.autosave.session}
+	return tea.Tick(m.autosave.idle, func(time.Time) tea.Msg { return msg })
+}
+
+func (m *NoteView) schedulePeriodic() tea.Cmd {
+	if m.autosave.idle <= 0 || m.autosave.interval <= 0 {
+		return nil
+	}
+	msg := AutosaveMsg{Path: m.Path, session: m.autosave.session, periodic: true}
+	return tea.Tick(m.autosave.interval, func(time.Time) tea.Msg { return msg })
+}
+
+func (m *NoteView) handleAutosave(msg AutosaveMsg) tea.Cmd {
+	if !m.isEditing || msg.Path != m.Path || msg.session != m.autosave.session {
+		return nil // editing ended or moved on to another note
+	}
+	var next tea.Cmd
+	if msg.periodic {
+		next = m.schedulePeriodic()
+	} else if msg.token != m.autosave.token {
+		return nil // typed again since
+	}
+	content := m.edited()
+	if m.conflict != nil || content == m.autosave.last {
+		return next
+	}
+	return tea.Batch(next, m.autosaveCmd(content, m.textarea.Value()))
+}
+
+// writes the note when asked to and nobody else changed it, a draft otherwise
+func (m *NoteView) autosaveCmd(content, value string) tea.Cmd {
+	path, store, stamp := m.Path, m.versions, m.stamp
+	direct, first := m.autosave.direct, !m.autosave.wrote
+	return func() tea.Msg {
+		if direct {
+			if _, changed, err := changedSince(path, stamp); err == nil && !changed {
+				if first { // the note before this session goes to the versions, once
+					if old, err := os.ReadFile(path); err == nil {
+						_ = store.Save(path, old)
+					}
+				}
+				err := filesystem.WriteFileAtomic(path, []byte(content))
+				if err == nil {
+					store.DropDraft(path)
+					return AutosavedMsg{Path: path, Content: content, Direct: true, stamp: stampOf(path, content), value: value}
+				}
+			}
+		}
+		return AutosavedMsg{Path: path, Content: content, Err: store.SaveDraft(path, []byte(content))}
+	}
+}
+
+func (m *NoteView) handleAutosaved(msg AutosavedMsg) {
+	if msg.Path != m.Path {
+		return
+	}
+	m.autosave.err = msg.Err
+	if msg.Err != nil {
+		return
+	}
+	m.autosave.last = msg.Content
+	m.autosave.at = time.Now()
+	if msg.Direct {
+		// the note on disk is what the editor started from now
+		m.rawContent = msg.Content
+		m.stamp = msg.stamp
+		m.baseline = msg.value
+		m.autosave.wrote = true
+	} else {
+		m.autosave.drafted = true
+	}
+}
+
+// the note was reloaded after leaving the editor if autosave wrote it
+func (m *NoteView) reloadAutosaved() tea.Cmd {
+	if !m.autosave.wrote {
+		return nil
+	}
+	m.autosave.wrote = false
+	path := m.Path
+	return func() tea.Msg {
+		loaded := fetchContent(path, 0)().(LoadedNote)
+		loaded.Saved = true
+		return loaded
+	}
+}
+
+func (m *NoteView) dropDraft() tea.Cmd {
+	if !m.autosave.drafted {
+		return nil
+	}
+	m.autosave.drafted = false
+	path, store := m.Path, m.versions
+	return func() tea.Msg {
+		store.DropDraft(path)
+		return nil
+	}
+}
+
+// drafts are checked when a note loads, newer ones than the note are offered
+func (m *NoteView) fetch(path string, section int) tea.Cmd {
+	store := m.versions
+	return func() tea.Msg {
+		loaded, ok := fetchContent(path, section)().(LoadedNote)
+		if ok && loaded.Err == nil && loaded.Kind == filesystem.KindNote {
+			if draft, found := store.Draft(path); found {
+				loaded.Draft = &draft
+			}
+		}
+		return loaded
+	}
+}
+
+// the keys while a draft is offered
+func (m *NoteView) draftKey(key string) tea.Cmd {
+	draft := m.draft
+	switch key {
+	case "r": // into the editor on top of the note, undo goes back to it. it stays a draft until saved
+		m.draft = nil
+		cmd := m.startEditing(m.rawContent)
+		m.pushUndo(m.snapshot())
+		m.textarea.SetValue(strings.ReplaceAll(draft.Content, "\r\n", "\n"))
+		m.autosave.last = draft.Content
+		m.autosave.drafted = true
+		return cmd
+	case "x":
+		m.draft = nil
+		m.autosave.drafted = true
+		return m.dropDraft()
+	case "esc": // ask again next time
+		m.draft = nil
+	}
+	return nil
+}
+
+func (m NoteView) renderDraftFooter() string {
+	found := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("unsaved edits from " + m.draft.Time.Local().Format("2006-01-02 15:04:05"))
+	keys := lipgloss.NewStyle().Faint(true).Render("  r restore  x drop  esc later")
+	return lipgloss.NewStyle().Width(m.vp.Width).Align(lipgloss.Right).Render(found + keys)
+}
+
+// how the last autosave went, for the editor footer
+func (m NoteView) autosaveStatus() string {
+	switch {
+	case m.autosave.err != nil:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("autosave failed: "+m.autosave.err.Error()) + "  "
+	case !m.autosave.at.IsZero() && m.autosave.wrote:
+		return lipgloss.NewStyle().Faint(true).Render("autosaved "+m.autosave.at.Format("15:04:05")) + "  "
+	case !m.autosave.at.IsZero():
+		return lipgloss.NewStyle().Faint(true).Render("draft kept "+m.autosave.at.Format("15:04:05")) + "  "
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package note
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mend/internal/versions"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runs the idle autosave the last edit scheduled
+func idleAutosave(m *NoteView) {
+	msg := AutosaveMsg{Path: m.Path, token: m.autosave.token, session: m.autosave.session}
+	if cmd := m.handleAutosave(msg); cmd != nil {
+		m.Update(cmd())
+	}
+}
+
+// tests keeping a draft while editing and offering it when the note opens again
+func TestAutosaveDraft(t *testing.T) {
+	root, err := os.MkdirTemp("", "mend_note_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, "a.md")
+	os.WriteFile(path, []byte("# A\n"), 0644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(path, old, old)
+	store := versions.NewStore([]string{root}, 5)
+
+	m := NewNoteView()
+	m.SetVersions(store)
+	m.SetAutosave(time.Second, time.Minute, false)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m.Update(m.fetch(path, 0)())
+	m.Path = path
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	typeText(m, "draft")
+	idleAutosave(m)
+	if m.autosave.at.IsZero() || m.autosave.err != nil {
+		t.Fatalf("expected a draft to be kept, got %v", m.autosave.err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# A\n" {
+		t.Errorf("expected the note untouched, got %q", data)
+	}
+
+	// the session ends without saving, the next one is offered the draft
+	next := NewNoteView()
+	next.SetVersions(store)
+	next.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	next.Path = path
+	next.Update(next.fetch(path, 0)())
+	if !next.HoldsKeys() {
+		t.Fatal("expected the draft to be offered")
+	}
+	next.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if !next.IsEditing() || next.textarea.Value() != "# A\ndraft" {
+		t.Errorf("expected the draft in the editor, got %q", next.textarea.Value())
+	}
+
+	// saving drops the draft
+	_, cmd := next.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	next.Update(cmd())
+	if _, found := store.Draft(path); found {
+		t.Error("expected the draft gone after saving")
+	}
+}
+
+// tests autosaving into the note itself and discarding back to the original
+func TestAutosaveToNote(t *testing.T) {
+	root, err := os.MkdirTemp("", "mend_note_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, "a.md")
+	os.WriteFile(path, []byte("# A\n"), 0644)
+
+	m := NewNoteView()
+	m.SetVersions(versions.NewStore([]string{root}, 5))
+	m.SetAutosave(time.Second, time.Minute, true)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m.Update(m.fetch(path, 0)())
+	m.Path = path
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	typeText(m, "more")
+	idleAutosave(m)
+	if data, _ := os.ReadFile(path); string(data) != "# A\nmore" {
+		t.Errorf("expected the note written, got %q", data)
+	}
+	if m.Dirty() {
+		t.Error("expected nothing left to save")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	m.Update(cmd())
+	if data, _ := os.ReadFile(path); string(data) != "# A\n" {
+		t.Errorf("expected discarding to put the note back, got %q", data)
+	}
+}
@@ -0,0 +1,84 @@
+package note
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// rows of the panel, one digit each to open them
+const maxBacklinkRows = 9
+
+// Backlink is a note linking to the open one
+type Backlink struct {
+	Path    string
+	Name    string // how to show the path
+	Snippet string // the line with the link
+}
+
+// OpenBacklinkMsg asks to go to a note linking here
+type OpenBacklinkMsg struct {
+	Backlink Backlink
+}
+
+func (m *NoteView) SetBacklinks(backlinks []Backlink) {
+	m.backlinks = backlinks
+	m.resizeViewport()
+}
+
+// the panel takes its lines from the note, the footer always keeps one
+func (m *NoteView) resizeViewport() {
+	m.vp.Height = max(0, m.height-1-m.backlinksHeight())
+}
+
+func (m *NoteView) backlinksHeight() int {
+	if !m.showBacklinks {
+		return 0
+	}
+	return 1 + max(1, min(len(m.backlinks), maxBacklinkRows))
+}
+
+func (m *NoteView) toggleBacklinks() {
+	m.showBacklinks = !m.showBacklinks
+	m.resizeViewport()
+}
+
+// a digit opens that backlink while the panel is shown
+func (m *NoteView) backlinkKey(key string) (tea.Cmd, bool) {
+	if !m.showBacklinks || len(key) != 1 || key[0] < '1' || key[0] > '9' {
+		return nil, false
+	}
+	i := int(key[0] - '1')
+	if i >= len(m.backlinks) {
+		return nil, true
+	}
+	backlink := m.backlinks[i]
+	return func() tea.Msg { return OpenBacklinkMsg{Backlink: backlink} }, true
+}
+
+func (m NoteView) renderBacklinks() string {
+	faint := lipgloss.NewStyle().Faint(true)
+	line := lipgloss.NewStyle().MaxWidth(m.vp.Width)
+
+	header := fmt.Sprintf("Backlinks (%d)", len(m.backlinks))
+	if len(m.backlinks) > maxBacklinkRows {
+		header += fmt.Sprintf(", first %d", maxBacklinkRows)
+	}
+	rows := []string{line.Render(faint.Render(header + "  press a number to open, b to hide"))}
+	if len(m.backlinks) == 0 {
+		rows = append(rows, line.Render(faint.Render("  no notes link here")))
+	}
+	for i, backlink := range m.backlinks {
+		if i == maxBacklinkRows {
+			break
+		}
+		row := fmt.Sprintf("%d %s", i+1, lipgloss.NewStyle().Bold(true).Render(backlink.Name))
+		if backlink.Snippet != "" {
+			row += "  " + faint.Render(backlink.Snippet)
+		}
+		rows = append(rows, line.Render(row))
+	}
+	return strings.Join(rows, "\n")
+}
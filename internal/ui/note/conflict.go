@@ -0,0 +1,151 @@
+/*
+notes edited in mend can change on disk at the same time, a sync tool or
+another editor writing them. the editor remembers what the file was when
+editing started and won't save over a different version without asking.
+*/
+
+package note
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mend/internal/versions"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// what the file looked like when editing started
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte
+}
+
+func stampOf(path, content string) fileStamp {
+	stamp := fileStamp{size: -1, sum: sha256.Sum256([]byte(content))}
+	if info, err := os.Stat(path); err == nil {
+		stamp.modTime, stamp.size = info.ModTime(), info.Size()
+	}
+	return stamp
+}
+
+// changedSince reads the file again when it doesn't look the same as when the
+// stamp was taken, a touched file with the same content isn't a change
+func changedSince(path string, stamp fileStamp) (string, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false, err
+	}
+	if info.ModTime().Equal(stamp.modTime) && info.Size() == stamp.size {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, err
+	}
+	if sha256.Sum256(data) == stamp.sum {
+		return "", false, nil
+	}
+	return string(data), true, nil
+}
+
+// ConflictMsg is sent instead of saving when the note changed on disk since
+// editing started
+type ConflictMsg struct {
+	Path   string
+	Base   string // the note when editing started
+	Mine   string // what the editor has
+	Theirs string // what is on disk now
+}
+
+// a conflict waiting for a choice
+type conflict struct {
+	base   string
+	theirs string
+}
+
+// saves content unless the file changed since stamp, then reports a conflict
+func saveChecked(store *versions.Store, path, base, content string, stamp fileStamp) tea.Cmd {
+	return func() tea.Msg {
+		theirs, changed, err := changedSince(path, stamp)
+		if err != nil {
+			return LoadedNote{Err: err}
+		}
+		if changed {
+			return ConflictMsg{Path: path, Base: base, Mine: content, Theirs: theirs}
+		}
+		return saveContent(store, path, content)()
+	}
+}
+
+// back into the editor with the choices shown below it
+func (m *NoteView) openConflict(msg ConflictMsg) {
+	if msg.Path != m.Path {
+		return
+	}
+	m.isEditing = true // the editor still has what was being saved
+	m.textarea.Focus()
+	m.conflict = &conflict{base: msg.Base, theirs: msg.Theirs}
+}
+
+// the keys while a conflict is shown, everything else is ignored
+func (m *NoteView) conflictKey(key string) tea.Cmd {
+	c := m.conflict
+	switch key {
+	case "k": // keep mine, overwriting the file
+		m.conflict = nil
+		content := m.edited()
+		m.stopEditing()
+		return saveContent(m.versions, m.Path, content)
+	case "t": // take theirs, dropping the edits
+		m.conflict = nil
+		m.stopEditing()
+		m.loading = true
+		return fetchContent(m.Path, m.currentSectionIndex)
+	case "m": // merge both into the editor, conflicts marked
+		m.conflict = nil
+		before := m.snapshot()
+		merged, _ := merge3(c.base, m.edited(), c.theirs)
+		m.textarea.SetValue(strings.ReplaceAll(merged, "\r\n", "\n"))
+		if m.scope != nil {
+			// the merge is of the whole note, a section can't be undone into it
+			m.scope = nil
+			m.history = editHistory{}
+		} else {
+			m.pushUndo(before)
+		}
+		// what's on disk is the new starting point
+		m.rawContent = c.theirs
+		m.stamp = stampOf(m.Path, c.theirs)
+		m.crlf = strings.Contains(c.theirs, "\r\n")
+	case "esc": // keep editing, saving will ask again
+		m.conflict = nil
+	}
+	return nil
+}
+
+func (m NoteView) renderConflictFooter() string {
+	warn := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(filepath.Base(m.Path) + " changed on disk")
+	keys := lipgloss.NewStyle().Faint(true).Render("  k keep mine  t take theirs  m merge  esc keep editing")
+	return lipgloss.NewStyle().MaxWidth(m.vp.Width).Render(warn + keys)
+}
+
+// conflict blocks a merge left in the editor
+func countConflicts(content string) int {
+	return strings.Count("\n"+content, "\n"+markerMine)
+}
+
+// shown in the editor footer until the markers are gone
+func (m NoteView) mergeStatus() string {
+	n := countConflicts(m.textarea.Value())
+	if n == 0 {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(fmt.Sprintf("%d conflicts to resolve", n)) + "  "
+}
@@ -0,0 +1,80 @@
+package note
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tests merging edits of different lines and marking edits of the same line
+func TestMerge3(t *testing.T) {
+	base := "# A\none\ntwo\nthree"
+
+	merged, conflicts := merge3(base, "# A\none!\ntwo\nthree", "# A\none\ntwo\nthree\nfour")
+	if merged != "# A\none!\ntwo\nthree\nfour" || conflicts != 0 {
+		t.Errorf("expected a clean merge, got %d conflicts in %q", conflicts, merged)
+	}
+
+	merged, conflicts = merge3(base, "# A\nmine\ntwo\nthree", "# A\ntheirs\ntwo")
+	want := "# A\n" + markerMine + "\nmine\n" + markerSplit + "\ntheirs\n" + markerTheirs + "\ntwo"
+	if merged != want || conflicts != 1 {
+		t.Errorf("expected one conflict, got %d in %q", conflicts, merged)
+	}
+	if countConflicts(merged) != 1 {
+		t.Error("expected the markers to be counted")
+	}
+}
+
+// tests that saving over a note changed on disk asks first, and that a merge
+// keeps the line endings of the note
+func TestSaveConflict(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_note_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for name, nl := range map[string]string{"lf.md": "\n", "crlf.md": "\r\n"} {
+		lines := func(s ...string) string { return strings.Join(s, nl) }
+		path := filepath.Join(tmpDir, name)
+		os.WriteFile(path, []byte(lines("# A", "one", "two")), 0644)
+
+		m := NewNoteView()
+		m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+		m.Update(fetchContent(path, 0)())
+		m.Path = path
+		m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m.Update(tea.KeyMsg{Type: tea.KeyCtrlHome})
+		typeText(m, "# ")
+
+		// another program changes the last line
+		os.WriteFile(path, []byte(lines("# A", "one", "two!")), 0644)
+		os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		msg, ok := cmd().(ConflictMsg)
+		if !ok {
+			t.Fatalf("%s: expected a conflict, got %T", name, msg)
+		}
+		m.Update(msg)
+		if !m.IsEditing() || m.conflict == nil {
+			t.Fatalf("%s: expected the editor back with the conflict", name)
+		}
+
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+		if got := m.textarea.Value(); got != "# # A\none\ntwo!" {
+			t.Errorf("%s: expected both edits merged, got %q", name, got)
+		}
+		_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		if loaded, ok := cmd().(LoadedNote); !ok || !loaded.Saved {
+			t.Fatalf("%s: expected the merge to be saved, got %+v", name, loaded)
+		}
+		if data, _ := os.ReadFile(path); string(data) != lines("# # A", "one", "two!") {
+			t.Errorf("%s: unexpected file after saving the merge %q", name, data)
+		}
+	}
+}
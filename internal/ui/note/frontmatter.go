@@ -0,0 +1,35 @@
+/*
+frontmatter in the editor: mend never rewrites the block unless it was edited.
+what a note says in it is read through internal/frontmatter.
+*/
+package note
+
+import (
+	"strings"
+
+	"mend/internal/frontmatter"
+)
+
+// keepFrontmatter puts the original frontmatter bytes back in front of an
+// edited note as long as the edit didn't change what it says. the editor
+// drops \r and trailing blanks, that alone shouldn't rewrite the block.
+func keepFrontmatter(original, edited string) string {
+	origFm, origBody, ok := frontmatter.Split([]byte(original))
+	if !ok {
+		return edited
+	}
+	editedFm, editedBody, ok := frontmatter.Split([]byte(edited))
+	if !ok || normalizeLines(origFm) != normalizeLines(editedFm) {
+		return edited
+	}
+	block := original[:len(original)-len(origBody)]
+	return block + string(editedBody)
+}
+
+func normalizeLines(b []byte) string {
+	lines := strings.Split(string(b), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
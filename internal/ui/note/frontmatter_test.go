@@ -0,0 +1,23 @@
+package note
+
+import (
+	"testing"
+)
+
+// tests that saving an edit keeps the block exactly as it was on disk
+func TestKeepFrontmatter(t *testing.T) {
+	original := "---\r\ntitle:  x   \r\ntags: [a]\r\n---\r\nold body\r\n"
+
+	// the editor gives back the block without \r and trailing blanks
+	edited := "---\ntitle:  x\ntags: [a]\n---\nnew body\n"
+	want := "---\r\ntitle:  x   \r\ntags: [a]\r\n---\r\nnew body\n"
+	if got := keepFrontmatter(original, edited); got != want {
+		t.Errorf("keepFrontmatter() = %q, want %q", got, want)
+	}
+
+	// real edits to the block win
+	edited = "---\ntitle: y\ntags: [a]\n---\nnew body\n"
+	if got := keepFrontmatter(original, edited); got != edited {
+		t.Errorf("expected the edited block to be saved, got %q", got)
+	}
+}
@@ -0,0 +1,121 @@
+package note
+
+import (
+	"strings"
+
+	"mend/internal/filesystem"
+	"mend/internal/links"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const maxCompletions = 6
+
+// FollowLinkMsg asks to open where a link in the note at From points
+type FollowLinkMsg struct {
+	From string
+	Link links.Link
+}
+
+// LinkPickerMsg asks for a pick list of the links in the note at From
+type LinkPickerMsg struct {
+	From  string
+	Links []links.Link
+}
+
+// SetLinkIndex gives the view what it needs to check links and complete them
+func (m *NoteView) SetLinkIndex(index *links.Index) {
+	m.links = index
+	m.broken = m.countBroken()
+}
+
+func (m *NoteView) countBroken() int {
+	if m.links == nil || m.kind != filesystem.KindNote {
+		return 0
+	}
+	count := 0
+	for _, link := range links.Parse([]byte(m.rawContent)) {
+		if _, ok := m.links.Resolve(link.Target, m.Path); !ok {
+			count++
+		}
+	}
+	return count
+}
+
+func (m *NoteView) linkPicker() tea.Cmd {
+	from := m.Path
+	found := links.Parse([]byte(m.rawContent))
+	return func() tea.Msg { return LinkPickerMsg{From: from, Links: found} }
+}
+
+// the line the textarea cursor is on, split at the cursor
+func (m *NoteView) cursorLine() (before, after string) {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	row := m.textarea.Line()
+	if row >= len(lines) {
+		return "", ""
+	}
+	line := []rune(lines[row])
+	info := m.textarea.LineInfo()
+	col := min(info.StartColumn+info.ColumnOffset, len(line))
+	return string(line[:col]), string(line[col:])
+}
+
+// follows the link under the cursor, saving first so nothing typed is lost
+func (m *NoteView) followAtCursor() tea.Cmd {
+	before, after := m.cursorLine()
+	link, ok := links.At(before+after, len([]rune(before)))
+	if !ok {
+		return nil
+	}
+	from := m.Path
+	return tea.Sequence(
+		m.save(),
+		func() tea.Msg { return FollowLinkMsg{From: from, Link: link} },
+	)
+}
+
+// offers targets while the cursor is inside an unclosed [[
+func (m *NoteView) updateCompletions() {
+	before, _ := m.cursorLine()
+	partial, ok := links.Partial(before)
+	if !ok || m.links == nil {
+		m.completions = nil
+		return
+	}
+	m.completions = m.links.Complete(partial, maxCompletions)
+	m.completionIndex = 0
+}
+
+// replaces what was typed after [[ with the picked target and closes the link
+func (m *NoteView) acceptCompletion() {
+	before, after := m.cursorLine()
+	partial, _ := links.Partial(before)
+	for range []rune(partial) {
+		m.textarea, _ = m.textarea.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	insert := m.completions[m.completionIndex]
+	if !strings.HasPrefix(after, "]]") {
+		insert += "]]"
+	}
+	m.textarea.InsertString(insert)
+	m.completions = nil
+}
+
+// one line below the editor, the completions or nothing
+func (m NoteView) renderCompletions() string {
+	if len(m.completions) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(m.completions))
+	for i, target := range m.completions {
+		if i == m.completionIndex {
+			target = lipgloss.NewStyle().Bold(true).Reverse(true).Render(target)
+		}
+		parts = append(parts, target)
+	}
+	hint := lipgloss.NewStyle().Faint(true).Render("  tab to insert, ctrl+n/ctrl+p to choose")
+	line := "[[ " + strings.Join(parts, "  ") + hint
+	return lipgloss.NewStyle().MaxWidth(m.vp.Width).Render(line)
+}
@@ -0,0 +1,115 @@
+package note
+
+import (
+	"slices"
+	"strings"
+)
+
+// lines compared pairwise at most, bigger differences become a single conflict
+const maxMergeCells = 4_000_000
+
+const (
+	markerMine   = "<<<<<<< mine"
+	markerSplit  = "======="
+	markerTheirs = ">>>>>>> on disk"
+)
+
+// merge3 merges two edits of base line by line. changes on one side only are
+// taken as they are, lines both sides changed differently end up between
+// conflict markers. conflicts is how many of those blocks there are.
+func merge3(base, mine, theirs string) (merged string, conflicts int) {
+	b := strings.Split(base, "\n")
+	a := strings.Split(mine, "\n")
+	t := strings.Split(theirs, "\n")
+	toMine := matchLines(b, a)
+	toTheirs := matchLines(b, t)
+
+	out := make([]string, 0, max(len(a), len(t)))
+	i, x, y := 0, 0, 0
+	for i <= len(b) {
+		// the next base line both sides kept
+		j := i
+		for j < len(b) && (toMine[j] < 0 || toTheirs[j] < 0) {
+			j++
+		}
+		endMine, endTheirs := len(a), len(t)
+		if j < len(b) {
+			endMine, endTheirs = toMine[j], toTheirs[j]
+		}
+
+		baseChunk, mineChunk, theirsChunk := b[i:j], a[x:endMine], t[y:endTheirs]
+		switch {
+		case slices.Equal(mineChunk, baseChunk):
+			out = append(out, theirsChunk...)
+		case slices.Equal(theirsChunk, baseChunk), slices.Equal(mineChunk, theirsChunk):
+			out = append(out, mineChunk...)
+		default:
+			out = append(out, markerMine)
+			out = append(out, mineChunk...)
+			out = append(out, markerSplit)
+			out = append(out, theirsChunk...)
+			out = append(out, markerTheirs)
+			conflicts++
+		}
+
+		if j == len(b) {
+			break
+		}
+		out = append(out, b[j])
+		i, x, y = j+1, endMine+1, endTheirs+1
+	}
+	return strings.Join(out, "\n"), conflicts
+}
+
+// matchLines pairs lines of from with equal lines of to along their longest
+// common subsequence, -1 where a line has no partner
+func matchLines(from, to []string) []int {
+	match := make([]int, len(from))
+	for i := range match {
+		match[i] = -1
+	}
+
+	// the same start and end is common, keep it out of the table
+	pre := 0
+	for pre < len(from) && pre < len(to) && from[pre] == to[pre] {
+		match[pre] = pre
+		pre++
+	}
+	suf := 0
+	for suf < len(from)-pre && suf < len(to)-pre && from[len(from)-1-suf] == to[len(to)-1-suf] {
+		match[len(from)-1-suf] = len(to) - 1 - suf
+		suf++
+	}
+	f, g := from[pre:len(from)-suf], to[pre:len(to)-suf]
+	if len(f) == 0 || len(g) == 0 || len(f)*len(g) > maxMergeCells {
+		return match
+	}
+
+	// lcs[i][j] is the longest common run of f[i:] and g[j:]
+	lcs := make([][]int32, len(f)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(g)+1)
+	}
+	for i := len(f) - 1; i >= 0; i-- {
+		for j := len(g) - 1; j >= 0; j-- {
+			if f[i] == g[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(f) && j < len(g); {
+		switch {
+		case f[i] == g[j]:
+			match[pre+i] = pre + j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
@@ -6,28 +6,28 @@ repetition in mend
 package note
 
 import (
+	"bytes"
 	"fmt"
 	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
+	"mend/internal/filesystem"
+	"mend/internal/frontmatter"
+	"mend/internal/links"
+	"mend/internal/sections"
+	"mend/internal/versions"
+
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	glStyles "github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/yuin/goldmark"
-	"github.com/yuin/goldmark/ast"
-	"github.com/yuin/goldmark/text"
 )
 
-type Section struct {
-	Title   string
-	Content string
-	Hints   []string
-}
-
 type ViewState int
 
 const (
@@ -39,9 +39,12 @@ const (
 type NoteView struct {
 	// the actual content
 	Path                string
-	rawContent          string    // full content for editing
-	sections            []Section // number of BLOCKS (heading separated)
+	rawContent          string             // full content for editing
+	sections            []sections.Section // number of BLOCKS (heading separated)
 	currentSectionIndex int
+	meta                frontmatter.Metadata // frontmatter, not part of any section
+	kind                filesystem.FileKind  // only notes are parsed and editable
+	size                int64
 	// display layer
 	err        error
 	loading    bool
@@ -49,22 +52,57 @@ type NoteView struct {
 	mdRenderer *glamour.TermRenderer
 	viewState  ViewState
 	// editing
-	textarea  textarea.Model
-	isEditing bool
+	textarea       textarea.Model
+	isEditing      bool
+	history        editHistory
+	confirmDiscard bool      // ctrl+x was pressed once with unsaved changes
+	stamp          fileStamp // the file when editing started
+	baseline       string    // the textarea's take on the text when editing started
+	crlf           bool      // the note has \r\n line endings, the textarea only knows \n
+	conflict       *conflict // the file changed on disk, waiting for a choice
+	autosave       autosaveState
+	scope          *editScope      // the section being edited, nil for the whole note
+	draft          *versions.Draft // unsaved edits from an earlier session, offered on opening
+	// links
+	links           *links.Index
+	broken          int      // links in the open note that go nowhere
+	completions     []string // link targets offered while typing [[
+	completionIndex int
+	backlinks       []Backlink
+	showBacklinks   bool
+	height          int
+	// old versions
+	versions *versions.Store
+	preview  *versionPreview
 }
 
 // ================== messages ===================
 type LoadNoteMsg struct {
-	Path  string
-	Force bool
+	Path    string
+	Force   bool
+	Section int // section to show once loaded, clamped to what the note has
+}
+
+// EndOfNoteMsg is sent when moving past the last section, a review moves on to the next note
+type EndOfNoteMsg struct {
+	Path string
 }
 
 type LoadedNote struct {
 	RawContent string
-	Sections   []Section
+	Sections   []sections.Section
+	Section    int
+	Meta       frontmatter.Metadata
+	Kind       filesystem.FileKind
+	Size       int64
 	Err        error
+	Saved      bool            // loaded again right after saving
+	Draft      *versions.Draft // unsaved edits newer than the note
 }
 
+// text files that aren't notes are shown as a code block up to this size
+const maxTextPreviewSize = 256 * 1024
+
 func newMdRenderer() *glamour.TermRenderer {
 	// styling in glamour can be better, I would rather have a fluent style api here
 	// https://github.com/charmbracelet/glamour/issues/294
@@ -92,7 +130,7 @@ func NewNoteView() *NoteView {
 	return &NoteView{
 		loading:    false,
 		mdRenderer: newMdRenderer(),
-		vp:         viewport.New(0, 0),
+		vp:         newViewport(),
 		viewState:  StateTitleOnly,
 		textarea:   newTextArea(),
 	}
@@ -106,35 +144,121 @@ func (m *NoteView) IsEditing() bool {
 	return m.isEditing
 }
 
+func (m *NoteView) CurrentSection() int {
+	return m.currentSectionIndex
+}
+
+// Meta is the frontmatter of the open note
+func (m *NoteView) Meta() frontmatter.Metadata {
+	return m.meta
+}
+
 func (m *NoteView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.vp.Width = msg.Width
-		m.vp.Height = msg.Height - 1
+		m.height = msg.Height
+		m.resizeViewport()
 		m.textarea.SetWidth(msg.Width)
-		m.textarea.SetHeight(msg.Height)
+		m.textarea.SetHeight(msg.Height - 1) // completions go below
+		return m, nil
+
+	case ConflictMsg:
+		m.openConflict(msg)
+		return m, nil
+
+	case AutosaveMsg:
+		return m, m.handleAutosave(msg)
+
+	case AutosavedMsg:
+		m.handleAutosaved(msg)
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.isEditing && m.conflict != nil {
+			return m, m.conflictKey(msg.String())
+		}
 		if m.isEditing {
+			if msg.String() != "ctrl+x" {
+				m.confirmDiscard = false
+			}
 			switch msg.String() {
 			case "esc", "ctrl+q":
-				m.isEditing = false
-				return m, saveContent(m.Path, m.textarea.Value())
+				return m, m.save()
+			case "ctrl+x":
+				return m, m.discard()
+			case "ctrl+z":
+				m.undo()
+				return m, m.scheduleIdle()
+			case "ctrl+y":
+				m.redo()
+				return m, m.scheduleIdle()
+			case "ctrl+]": // follow the link under the cursor
+				if cmd := m.followAtCursor(); cmd != nil {
+					return m, cmd
+				}
+				return m, nil
 			}
+			if len(m.completions) > 0 {
+				switch msg.String() {
+				case "tab":
+					before := m.snapshot()
+					m.acceptCompletion()
+					m.pushUndo(before)
+					m.history.last = ""
+					return m, nil
+				case "ctrl+n":
+					m.completionIndex = (m.completionIndex + 1) % len(m.completions)
+					return m, nil
+				case "ctrl+p":
+					m.completionIndex = (m.completionIndex + len(m.completions) - 1) % len(m.completions)
+					return m, nil
+				}
+			}
+			before := m.snapshot()
 			var cmd tea.Cmd
 			m.textarea, cmd = m.textarea.Update(msg)
+			m.recordEdit(before, msg)
+			m.updateCompletions()
+			if m.textarea.Value() != before.value {
+				cmd = tea.Batch(cmd, m.scheduleIdle())
+			}
 			return m, cmd
 		}
 
+		if m.preview != nil {
+			return m, m.previewKey(msg)
+		}
+		if m.draft != nil {
+			return m, m.draftKey(msg.String())
+		}
+		if cmd, ok := m.backlinkKey(msg.String()); ok {
+			return m, cmd
+		}
 		switch msg.String() {
-		case "enter":
-			if m.Path != "" && !m.loading {
-				m.isEditing = true
-				m.textarea.SetValue(m.rawContent)
-				m.textarea.Focus()
-				return m, textarea.Blink
+		case "enter": // the section on screen
+			if m.Path != "" && !m.loading && m.kind == filesystem.KindNote {
+				return m, tea.Batch(m.editSection(), textarea.Blink)
+			}
+		case "alt+enter": // the whole note
+			if m.Path != "" && !m.loading && m.kind == filesystem.KindNote {
+				return m, tea.Batch(m.startEditing(m.rawContent), textarea.Blink)
 			}
+		case "b": // before the viewport, which pages up on it
+			if m.Path != "" && m.kind == filesystem.KindNote {
+				m.toggleBacklinks()
+			}
+			return m, nil
+		case "v": // old versions of the note
+			if m.Path != "" && !m.loading && m.kind == filesystem.KindNote {
+				return m, m.showVersions()
+			}
+			return m, nil
+		case "l": // pick one of the note's links
+			if m.Path != "" && !m.loading && m.kind == filesystem.KindNote {
+				return m, m.linkPicker()
+			}
+			return m, nil
 		case " ":
 			switch m.viewState {
 			case StateTitleOnly:
@@ -163,8 +287,13 @@ func (m *NoteView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.currentSectionIndex++
 				m.vp.SetContent(m.renderNote())
 				m.vp.GotoTop()
+				return m, nil
 			}
-			return m, nil
+			if m.Path == "" || m.loading {
+				return m, nil
+			}
+			path := m.Path
+			return m, func() tea.Msg { return EndOfNoteMsg{Path: path} }
 		}
 		var cmd tea.Cmd
 		m.vp, cmd = m.vp.Update(msg)
@@ -175,18 +304,33 @@ func (m *NoteView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil //noop
 		}
 		m.Path = msg.Path
-		m.isEditing = false
+		m.conflict = nil
+		m.preview = nil
+		m.draft = nil
+		m.stopEditing()
 		m.loading = true
 		m.currentSectionIndex = 0
-		return m, fetchContent(msg.Path)
+		return m, m.fetch(msg.Path, msg.Section)
 
 	case LoadedNote:
 		m.loading = false
 		m.rawContent = msg.RawContent
+		m.meta = msg.Meta
 		m.sections = msg.Sections
+		m.kind = msg.Kind
+		m.size = msg.Size
 		m.err = msg.Err
-		m.currentSectionIndex = 0
+		m.draft = msg.Draft
+		section := msg.Section
+		if msg.Saved {
+			section = m.currentSectionIndex // stay on what was just edited
+		}
+		m.currentSectionIndex = max(0, min(section, len(m.sections)-1))
+		m.broken = m.countBroken()
 		m.viewState = StateTitleOnly
+		if m.kind != filesystem.KindNote {
+			m.viewState = StateContent // nothing to recall in a preview
+		}
 		m.vp.SetContent(m.renderNote())
 
 		return m, nil
@@ -215,7 +359,7 @@ func (m NoteView) View() string {
 	}
 
 	if m.isEditing {
-		return m.textarea.View()
+		return m.textarea.View() + "\n" + m.renderEditFooter()
 	}
 
 	page := m.currentSectionIndex + 1
@@ -226,102 +370,87 @@ func (m NoteView) View() string {
 	} else {
 		footer = fmt.Sprintf("%d/%d", page, total)
 	}
+	if m.broken > 0 {
+		footer = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(fmt.Sprintf("%d broken links", m.broken)) + "  " + footer
+	}
 	footer = lipgloss.NewStyle().Width(m.vp.Width).Align(lipgloss.Right).Render(footer)
 
+	if m.preview != nil {
+		footer = m.renderPreviewFooter()
+	} else if m.draft != nil {
+		footer = m.renderDraftFooter()
+	}
+
+	if m.showBacklinks {
+		return m.vp.View() + "\n" + footer + "\n" + m.renderBacklinks()
+	}
 	return m.vp.View() + "\n" + footer
 }
 
-func fetchContent(path string) tea.Cmd {
+func fetchContent(path string, section int) tea.Cmd {
 	return func() tea.Msg {
+		if kind := filesystem.KindOf(path); kind != filesystem.KindNote {
+			return fetchAttachment(path, kind)
+		}
 		data, err := os.ReadFile(path)
 		if err != nil {
 			return LoadedNote{Err: err}
 		}
 
-		rawContent := string(data)
-		sections := ParseSections(data)
-
 		return LoadedNote{
-			RawContent: rawContent,
-			Sections:   sections,
+			RawContent: string(data),
+			Meta:       frontmatter.Parse(data),
+			Sections:   sections.Parse(data),
+			Section:    section,
 		}
 	}
 }
 
-func ParseSections(source []byte) []Section {
-	md := goldmark.New()
-	reader := text.NewReader(source)
-	doc := md.Parser().Parse(reader)
-
-	title := "no title"
-	sections := make([]Section, 0)
-	lastPos := 0
-
-	for child := doc.FirstChild(); child != nil; child = child.NextSibling() {
-		if child.Kind() == ast.KindHeading {
-			if child.Lines().Len() <= 0 {
-				continue
-			}
-
-			level := child.(*ast.Heading).Level
-			headingStart := child.Lines().At(0).Start - level - 1
-			headingEnd := child.Lines().At(child.Lines().Len() - 1).Stop
-
-			contentEnd := headingStart
-			if lastPos < contentEnd {
-				// you have a heading and a content to accumulte over
-				contentsRaw := source[lastPos:contentEnd]
-				contents := strings.TrimSpace(string(contentsRaw))
-				hints := ExtractHints(contents)
-				sections = append(sections, Section{
-					Title:   title,
-					Content: contents,
-					Hints:   hints,
-				})
-				lastPos = headingEnd
-			}
-			// for the next heading
-			title = strings.TrimSpace(string(source[headingStart:headingEnd]))
-			lastPos = headingEnd
-		}
+// anything that isn't a note never goes through the markdown parser, small
+// text files get a code block preview and everything else just a description
+func fetchAttachment(path string, kind filesystem.FileKind) LoadedNote {
+	info, err := os.Stat(path)
+	if err != nil {
+		return LoadedNote{Kind: kind, Err: err}
 	}
-	// last section
-	if lastPos < len(source) {
-		contentsRaw := source[lastPos:]
-		contents := strings.TrimSpace(string(contentsRaw))
-		hints := ExtractHints(contents)
-		sections = append(sections, Section{
-			Title:   title,
-			Content: contents,
-			Hints:   hints,
-		})
+	loaded := LoadedNote{Kind: kind, Size: info.Size()}
+	if kind != filesystem.KindOther || info.Size() > maxTextPreviewSize {
+		return loaded
 	}
 
-	return sections
+	data, err := os.ReadFile(path)
+	if err != nil {
+		loaded.Err = err
+		return loaded
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return loaded // binary
+	}
+	loaded.RawContent = string(data)
+	loaded.Sections = []sections.Section{{
+		Title:   "# " + filepath.Base(path),
+		Content: "````" + strings.TrimPrefix(filepath.Ext(path), ".") + "\n" + string(data) + "\n````",
+		Hints:   []string{},
+	}}
+	return loaded
 }
 
-func saveContent(path, content string) tea.Cmd {
+// saveContent replaces the note without a window where it's half written and
+// keeps what it was before in the version store
+func saveContent(store *versions.Store, path, content string) tea.Cmd {
 	return func() tea.Msg {
-		err := os.WriteFile(path, []byte(content), 0644)
-		if err != nil {
-			return LoadedNote{Err: err}
+		if old, err := os.ReadFile(path); err == nil {
+			// a version that can't be kept shouldn't stop the save
+			_ = store.Save(path, old)
 		}
-		return fetchContent(path)()
-	}
-}
-
-func ExtractHints(content string) []string {
-	re := regexp.MustCompile(`(?s)\*\*(.*?)\*\*|__(.*?)__`)
-	matches := re.FindAllStringSubmatch(content, -1)
-	hints := make([]string, 0)
-	for _, match := range matches {
-		if len(match) > 1 && match[1] != "" {
-			hints = append(hints, match[1])
-		} else if len(match) > 2 && match[2] != "" {
-			hints = append(hints, match[2])
+		if err := filesystem.WriteFileAtomic(path, []byte(content)); err != nil {
+			return LoadedNote{Err: err}
 		}
+		store.DropDraft(path)
+		loaded := fetchContent(path, 0)().(LoadedNote)
+		loaded.Saved = true
+		return loaded
 	}
-	return hints
 }
 
 func (m NoteView) renderNote() string {
@@ -329,7 +458,11 @@ func (m NoteView) renderNote() string {
 		return ""
 	}
 
-	section := Section{Content: m.rawContent} // default section if no sections are present
+	if m.kind != filesystem.KindNote && len(m.sections) == 0 {
+		return m.renderAttachmentInfo()
+	}
+
+	section := sections.Section{Content: m.rawContent} // default section if no sections are present
 	if m.currentSectionIndex < len(m.sections) {
 		section = m.sections[m.currentSectionIndex]
 	}
@@ -371,3 +504,32 @@ func (m NoteView) renderNote() string {
 
 	return "\n\n" + title + body
 }
+
+func (m NoteView) renderAttachmentInfo() string {
+	info := fmt.Sprintf("# %s\n\n**%s**, %s\n\nno preview, press `o` to open it externally",
+		filepath.Base(m.Path), m.kind, formatSize(m.size))
+	rendered, err := m.mdRenderer.Render(info)
+	if err != nil {
+		return info
+	}
+	return "\n\n" + rendered
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// ctrl+u and ctrl+d belong to the tree and d is the next section, half pages
+// are K and J next to k and j scrolling a line
+func newViewport() viewport.Model {
+	vp := viewport.New(0, 0)
+	vp.KeyMap.HalfPageUp = key.NewBinding(key.WithKeys("K"))
+	vp.KeyMap.HalfPageDown = key.NewBinding(key.WithKeys("J"))
+	return vp
+}
@@ -1,107 +1,43 @@
 package note
 
 import (
-	"reflect"
+	"mend/internal/filesystem"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
-// tests hint extraction from text
-func TestExtractHints(t *testing.T) {
-	tests := []struct {
-		name     string
-		content  string
-		expected []string
-	}{
-		{
-			name:     "single bold hint",
-			content:  "This is a **hint**.",
-			expected: []string{"hint"},
-		},
-		{
-			name:     "multiple bold hints",
-			content:  "**one** and **two**",
-			expected: []string{"one", "two"},
-		},
-		{
-			name:     "underscore hints",
-			content:  "__one__ and __two__",
-			expected: []string{"one", "two"},
-		},
-		{
-			name:     "mixed hints",
-			content:  "**one** and __two__",
-			expected: []string{"one", "two"},
-		},
-		{
-			name:     "no hints",
-			content:  "just plain text",
-			expected: []string{},
-		},
+// tests that attachments are never parsed as markdown
+func TestFetchAttachment(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_note_test")
+	if err != nil {
+		t.Fatal(err)
 	}
+	defer os.RemoveAll(tmpDir)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := ExtractHints(tt.content)
-			if !reflect.DeepEqual(got, tt.expected) {
-				t.Errorf("ExtractHints() = %v, want %v", got, tt.expected)
-			}
-		})
-	}
-}
-
-// tests section parsing from markdown
-func TestParseSections(t *testing.T) {
-	content := []byte(`# Title 1
-Content 1 with **hint1**.
-
-# Title 2
-Content 2 with __hint2__.
-`)
-
-	sections := ParseSections(content)
-
-	if len(sections) != 2 {
-		t.Fatalf("expected 2 sections, got %d", len(sections))
-	}
-
-	// verify first section
-	if sections[0].Title != "# Title 1" {
-		t.Errorf("expected title '# Title 1', got '%s'", sections[0].Title)
-	}
-	if sections[0].Content != "Content 1 with **hint1**." {
-		t.Errorf("expected content match for section 1, got '%s'", sections[0].Content)
-	}
-	if len(sections[0].Hints) != 1 || sections[0].Hints[0] != "hint1" {
-		t.Error("failed to extract hint from section 1")
-	}
+	text := filepath.Join(tmpDir, "script.sh")
+	os.WriteFile(text, []byte("# not a heading\necho hi"), 0644)
+	binary := filepath.Join(tmpDir, "blob.bin")
+	os.WriteFile(binary, []byte{0x00, 0xff, 0x10}, 0644)
+	image := filepath.Join(tmpDir, "photo.png")
+	os.WriteFile(image, []byte("fake"), 0644)
 
-	// verify second section
-	if sections[1].Title != "# Title 2" {
-		t.Errorf("expected title '# Title 2', got '%s'", sections[1].Title)
-	}
-	if sections[1].Content != "Content 2 with __hint2__." {
-		t.Errorf("expected content match for section 2, got '%s'", sections[1].Content)
+	loaded := fetchContent(text, 0)().(LoadedNote)
+	if loaded.Kind != filesystem.KindOther || len(loaded.Sections) != 1 {
+		t.Fatalf("expected a single preview section for text, got %+v", loaded)
 	}
-	if len(sections[1].Hints) != 1 || sections[1].Hints[0] != "hint2" {
-		t.Error("failed to extract hint from section 2")
+	if !strings.HasPrefix(loaded.Sections[0].Content, "````sh") {
+		t.Errorf("expected code block preview, got %q", loaded.Sections[0].Content)
 	}
-}
-
-// tests parsing with no headers
-func TestParseSectionsNoHeaders(t *testing.T) {
-	content := []byte(`Just some content without any headers.
-It has **one hint**.`)
 
-	sections := ParseSections(content)
-
-	if len(sections) != 1 {
-		t.Fatalf("expected 1 section, got %d", len(sections))
+	loaded = fetchContent(binary, 0)().(LoadedNote)
+	if len(loaded.Sections) != 0 || loaded.Size != 3 {
+		t.Errorf("expected no preview for binary, got %+v", loaded)
 	}
 
-	if sections[0].Title != "no title" {
-		t.Errorf("expected 'no title', got '%s'", sections[0].Title)
-	}
-	if len(sections[0].Hints) != 1 || sections[0].Hints[0] != "one hint" {
-		t.Error("failed to extract hint")
+	loaded = fetchContent(image, 0)().(LoadedNote)
+	if loaded.Kind != filesystem.KindImage || len(loaded.Sections) != 0 {
+		t.Errorf("expected image to be described only, got %+v", loaded)
 	}
 }
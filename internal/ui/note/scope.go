@@ -0,0 +1,76 @@
+package note
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// the part of the file the editor has when editing a single section. the end
+// is kept as the length of what follows, that doesn't move when the section grows
+type editScope struct {
+	index   int // the section, for the footer
+	start   int
+	tailLen int
+}
+
+// the section being edited as it is in the file
+func (s editScope) text(raw string) string {
+	return raw[s.start : len(raw)-s.tailLen]
+}
+
+// splice puts edited back in place of the section, the rest of the file stays as it is
+func (s editScope) splice(raw, edited string) string {
+	tail := raw[len(raw)-s.tailLen:]
+	if tail != "" && !strings.HasSuffix(edited, "\n") {
+		// the next heading has to stay on its own line
+		if strings.Contains(raw, "\r\n") {
+			edited += "\r\n"
+		} else {
+			edited += "\n"
+		}
+	}
+	return raw[:s.start] + edited + tail
+}
+
+// the scope of the section on screen, false when the whole file should be edited
+func (m *NoteView) sectionScope() (editScope, bool) {
+	if m.currentSectionIndex >= len(m.sections) {
+		return editScope{}, false
+	}
+	section := m.sections[m.currentSectionIndex]
+	if section.Start < 0 || section.End > len(m.rawContent) || section.Start > section.End {
+		return editScope{}, false
+	}
+	return editScope{index: m.currentSectionIndex, start: section.Start, tailLen: len(m.rawContent) - section.End}, true
+}
+
+// editSection opens the editor on the section on screen only, a note with a
+// single section is edited whole
+func (m *NoteView) editSection() tea.Cmd {
+	scope, ok := m.sectionScope()
+	if !ok || len(m.sections) < 2 {
+		return m.startEditing(m.rawContent)
+	}
+	cmd := m.startEditing(scope.text(m.rawContent))
+	m.scope = &scope
+	return cmd
+}
+
+// SectionLine is the line (1 based) the section on screen starts on in the file
+func (m *NoteView) SectionLine() int {
+	if m.currentSectionIndex >= len(m.sections) {
+		return 1
+	}
+	start := min(m.sections[m.currentSectionIndex].Start, len(m.rawContent))
+	return strings.Count(m.rawContent[:start], "\n") + 1
+}
+
+// what the editor is working on, for the footer
+func (m NoteView) scopeStatus() string {
+	if m.scope == nil {
+		return "whole note"
+	}
+	return fmt.Sprintf("section %d/%d", m.scope.index+1, len(m.sections))
+}
@@ -0,0 +1,46 @@
+package note
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tests editing one section and splicing it back
+func TestEditSection(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_note_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	path := filepath.Join(tmpDir, "a.md")
+	os.WriteFile(path, []byte("# A\na\n# B\nb\n# C\nc\n"), 0644)
+
+	m := NewNoteView()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m.Update(fetchContent(path, 1)())
+	m.Path = path
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.textarea.Value(); got != "# B\nb\n" {
+		t.Fatalf("expected only section B in the editor, got %q", got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyBackspace}) // the newline before C goes, it's put back
+	typeText(m, " more")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m.Update(cmd())
+	if data, _ := os.ReadFile(path); string(data) != "# A\na\n# B\nb more\n# C\nc\n" {
+		t.Errorf("unexpected file after saving the section %q", data)
+	}
+	if m.CurrentSection() != 1 {
+		t.Errorf("expected to stay on section B, got %d", m.CurrentSection())
+	}
+
+	// alt+enter edits the whole note
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	if got := m.textarea.Value(); got != "# A\na\n# B\nb more\n# C\nc\n" {
+		t.Errorf("expected the whole note in the editor, got %q", got)
+	}
+}
@@ -0,0 +1,194 @@
+/*
+undo history of the editor. every change keeps what the text looked like
+before it, typing a word or deleting a run of characters counts as one change
+instead of one per key.
+*/
+
+package note
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// changes kept, the oldest go first
+const maxUndo = 200
+
+// the text and where the cursor was
+type snapshot struct {
+	value    string
+	row, col int
+}
+
+type editHistory struct {
+	undo []snapshot
+	redo []snapshot
+	last string // kind of the last change, the next one of the same kind joins it
+}
+
+// kinds of change that join into one undo step
+const (
+	editTyping   = "typing"
+	editDeleting = "deleting"
+)
+
+// opens the editor on content with a fresh history
+func (m *NoteView) startEditing(content string) tea.Cmd {
+	m.isEditing = true
+	m.confirmDiscard = false
+	m.history = editHistory{}
+	m.scope = nil
+	m.stamp = stampOf(m.Path, m.rawContent)
+	m.crlf = strings.Contains(m.rawContent, "\r\n")
+	m.textarea.SetValue(strings.ReplaceAll(content, "\r\n", "\n"))
+	// tabs come back as spaces, that alone isn't an edit
+	m.baseline = m.textarea.Value()
+	return tea.Batch(m.textarea.Focus(), m.startAutosave())
+}
+
+// the history stays until editing starts again, a conflict on saving goes back to it
+func (m *NoteView) stopEditing() {
+	m.isEditing = false
+	m.confirmDiscard = false
+	m.completions = nil
+}
+
+// edited is what saving would write, Dirty says if that differs from the file.
+// the file stays as it is until the text is actually changed
+func (m *NoteView) edited() string {
+	value := m.textarea.Value()
+	if value == m.baseline {
+		return m.rawContent
+	}
+	if m.crlf {
+		value = strings.ReplaceAll(value, "\n", "\r\n")
+	}
+	if m.scope != nil {
+		return m.scope.splice(m.rawContent, value)
+	}
+	return keepFrontmatter(m.rawContent, value)
+}
+
+// Dirty is true while the editor has changes that aren't saved
+func (m *NoteView) Dirty() bool {
+	return m.isEditing && m.edited() != m.rawContent
+}
+
+func (m *NoteView) snapshot() snapshot {
+	info := m.textarea.LineInfo()
+	return snapshot{value: m.textarea.Value(), row: m.textarea.Line(), col: info.StartColumn + info.ColumnOffset}
+}
+
+// puts the text back and the cursor where it was
+func (m *NoteView) restore(s snapshot) {
+	m.textarea.SetValue(s.value) // leaves the cursor at the end
+	for m.textarea.Line() > s.row {
+		m.textarea.CursorUp()
+	}
+	m.textarea.SetCursor(s.col)
+	m.completions = nil
+}
+
+// keeps before as an undo step unless the change continues the last one
+func (m *NoteView) recordEdit(before snapshot, msg tea.KeyMsg) {
+	if m.textarea.Value() == before.value {
+		m.history.last = "" // moving around ends a word
+		return
+	}
+	kind := ""
+	switch {
+	case msg.Type == tea.KeyRunes && !msg.Paste:
+		kind = editTyping
+	case msg.Type == tea.KeyBackspace || msg.Type == tea.KeyDelete:
+		kind = editDeleting
+	}
+	if kind == "" || kind != m.history.last {
+		m.pushUndo(before)
+	}
+	m.history.last = kind
+	if kind == editTyping && strings.ContainsAny(string(msg.Runes), " \t") {
+		m.history.last = "" // the next word is its own step
+	}
+}
+
+func (m *NoteView) pushUndo(before snapshot) {
+	m.history.undo = append(m.history.undo, before)
+	if len(m.history.undo) > maxUndo {
+		m.history.undo = m.history.undo[1:]
+	}
+	m.history.redo = nil
+}
+
+func (m *NoteView) undo() {
+	if len(m.history.undo) == 0 {
+		return
+	}
+	last := len(m.history.undo) - 1
+	m.history.redo = append(m.history.redo, m.snapshot())
+	m.restore(m.history.undo[last])
+	m.history.undo = m.history.undo[:last]
+	m.history.last = ""
+}
+
+func (m *NoteView) redo() {
+	if len(m.history.redo) == 0 {
+		return
+	}
+	last := len(m.history.redo) - 1
+	m.history.undo = append(m.history.undo, m.snapshot())
+	m.restore(m.history.redo[last])
+	m.history.redo = m.history.redo[:last]
+	m.history.last = ""
+}
+
+// saves and leaves the editor, a note that didn't change isn't written and
+// one that changed on disk meanwhile comes back as a ConflictMsg
+func (m *NoteView) save() tea.Cmd {
+	dirty := m.Dirty()
+	content := m.edited()
+	m.stopEditing()
+	if !dirty {
+		return tea.Batch(m.reloadAutosaved(), m.dropDraft())
+	}
+	return saveChecked(m.versions, m.Path, m.rawContent, content, m.stamp)
+}
+
+// leaves the editor without saving, with unsaved changes only on the second press.
+// whatever autosave wrote to the note goes back to how it was.
+func (m *NoteView) discard() tea.Cmd {
+	if (m.Dirty() || m.autosave.wrote) && !m.confirmDiscard {
+		m.confirmDiscard = true
+		return nil
+	}
+	m.stopEditing()
+	if m.autosave.wrote {
+		m.autosave.wrote = false
+		return saveContent(m.versions, m.Path, m.autosave.base)
+	}
+	return m.dropDraft()
+}
+
+// one line below the editor: completions while typing a link, otherwise the keys
+// and whether there is anything to save
+func (m NoteView) renderEditFooter() string {
+	if m.conflict != nil {
+		return m.renderConflictFooter()
+	}
+	if len(m.completions) > 0 {
+		return m.renderCompletions()
+	}
+	faint := lipgloss.NewStyle().Faint(true)
+	if m.confirmDiscard {
+		warn := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("unsaved changes, ctrl+x again to discard them")
+		return lipgloss.NewStyle().MaxWidth(m.vp.Width).Render(warn)
+	}
+	keys := m.mergeStatus() + m.autosaveStatus() + faint.Render(m.scopeStatus()+"  esc save  ctrl+z undo  ctrl+y redo  ctrl+x discard")
+	status := faint.Render("unchanged")
+	if m.Dirty() {
+		status = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("modified")
+	}
+	gap := max(1, m.vp.Width-lipgloss.Width(keys)-lipgloss.Width(status))
+	return lipgloss.NewStyle().MaxWidth(m.vp.Width).Render(keys + strings.Repeat(" ", gap) + status)
+}
@@ -0,0 +1,111 @@
+package note
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func typeText(m *NoteView, text string) {
+	for _, r := range text {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// tests undo and redo by words, the dirty state and skipping unchanged saves
+func TestEditorUndo(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_note_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	path := filepath.Join(tmpDir, "a.md")
+	os.WriteFile(path, []byte("# A\n"), 0644)
+
+	m := NewNoteView()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m.Update(fetchContent(path, 0)())
+	m.Path = path
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.IsEditing() || m.Dirty() {
+		t.Fatal("expected a clean editor")
+	}
+
+	typeText(m, "one two")
+	if !m.Dirty() {
+		t.Error("expected the editor to be dirty after typing")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if got := m.textarea.Value(); got != "# A\none " {
+		t.Errorf("expected the last word undone, got %q", got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if m.Dirty() {
+		t.Errorf("expected the original text back, got %q", m.textarea.Value())
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	if got := m.textarea.Value(); got != "# A\none two" {
+		t.Errorf("expected both words redone, got %q", got)
+	}
+
+	// discarding asks once while there are changes
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	if !m.IsEditing() {
+		t.Error("expected the first ctrl+x to only warn")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	if m.IsEditing() {
+		t.Error("expected the second ctrl+x to leave the editor")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# A\n" {
+		t.Errorf("expected nothing written, got %q", data)
+	}
+
+	// leaving without changes writes nothing
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc}); cmd != nil || m.IsEditing() {
+		t.Error("expected no save for an unchanged note")
+	}
+}
+
+// tests that tabs and \r\n the textarea can't show aren't taken for edits,
+// and that an edited \r\n note keeps its line endings
+func TestEditorKeepsFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "mend_note_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tabs := filepath.Join(tmpDir, "tabs.md")
+	os.WriteFile(tabs, []byte("# A\n\tcode\n"), 0644)
+	m := NewNoteView()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m.Update(fetchContent(tabs, 0)())
+	m.Path = tabs
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.Dirty() {
+		t.Error("expected tabs shown as spaces not to count as a change")
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc}); cmd != nil {
+		t.Error("expected no save for an unchanged note")
+	}
+
+	crlf := filepath.Join(tmpDir, "crlf.md")
+	os.WriteFile(crlf, []byte("---\r\ntags: a\r\n---\r\n# A\r\ntext\r\n"), 0644)
+	m = NewNoteView()
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m.Update(fetchContent(crlf, 0)())
+	m.Path = crlf
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.Dirty() {
+		t.Errorf("expected a clean editor, has %q", m.textarea.Value())
+	}
+	typeText(m, " more")
+	if got, want := m.edited(), "---\r\ntags: a\r\n---\r\n# A\r\ntext\r\n more"; got != want {
+		t.Errorf("edited() = %q, want %q", got, want)
+	}
+}
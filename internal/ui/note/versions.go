@@ -0,0 +1,82 @@
+package note
+
+import (
+	"os"
+
+	"mend/internal/versions"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ShowVersionsMsg asks for the list of old versions of the open note
+type ShowVersionsMsg struct {
+	Path     string
+	Versions []versions.Version
+}
+
+// an old version shown in place of the note until it's restored or closed
+type versionPreview struct {
+	version versions.Version
+	content string
+}
+
+// SetVersions is where saves keep the previous content of notes
+func (m *NoteView) SetVersions(store *versions.Store) {
+	m.versions = store
+}
+
+// HoldsKeys is true while an old version or a draft to restore is shown,
+// the note view needs every key then
+func (m *NoteView) HoldsKeys() bool {
+	return m.preview != nil || m.draft != nil
+}
+
+func (m *NoteView) showVersions() tea.Cmd {
+	path, found := m.Path, m.versions.List(m.Path)
+	return func() tea.Msg { return ShowVersionsMsg{Path: path, Versions: found} }
+}
+
+// PreviewVersion shows an old version of the open note
+func (m *NoteView) PreviewVersion(version versions.Version) error {
+	data, err := os.ReadFile(version.Path)
+	if err != nil {
+		return err
+	}
+	m.preview = &versionPreview{version: version, content: string(data)}
+	rendered, err := m.mdRenderer.Render(string(data))
+	if err != nil {
+		rendered = string(data)
+	}
+	m.vp.SetContent(rendered)
+	m.vp.GotoTop()
+	return nil
+}
+
+func (m *NoteView) closePreview() {
+	m.preview = nil
+	m.vp.SetContent(m.renderNote())
+	m.vp.GotoTop()
+}
+
+// r puts the version back, what it replaces becomes a version itself
+func (m *NoteView) previewKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "r":
+		content := m.preview.content
+		m.closePreview()
+		return saveContent(m.versions, m.Path, content)
+	case "esc", "q", "v":
+		m.closePreview()
+		return nil
+	}
+	var cmd tea.Cmd
+	m.vp, cmd = m.vp.Update(msg)
+	return cmd
+}
+
+func (m NoteView) renderPreviewFooter() string {
+	at := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("version of " + m.preview.version.Time.Local().Format("2006-01-02 15:04:05"))
+	keys := lipgloss.NewStyle().Faint(true).Render("  r restore  esc close")
+	return lipgloss.NewStyle().Width(m.vp.Width).Align(lipgloss.Right).Render(at + keys)
+}
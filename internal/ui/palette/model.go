@@ -0,0 +1,197 @@
+/*
+palette ui model, a full screen pick list with a filter on top. it knows
+nothing about what it lists, whoever opens it gives it an id and items and
+gets the id back with the choice.
+*/
+package palette
+
+import (
+	"strings"
+
+	"mend/styles"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Item struct {
+	Title  string
+	Detail string // shown faint after the title, also matched by the filter
+	Value  string // handed back on select
+}
+
+type Palette struct {
+	id            string
+	title         string
+	input         textinput.Model
+	items         []Item
+	matches       []Item
+	selectedIndex int
+	width         int
+	height        int
+	active        bool
+}
+
+func New() *Palette {
+	ti := textinput.New()
+	ti.Placeholder = "Filter..."
+	ti.CharLimit = 256
+	ti.Width = 50
+
+	return &Palette{
+		input: ti,
+	}
+}
+
+// SelectMsg is sent when an item is picked
+type SelectMsg struct {
+	ID   string
+	Item Item
+}
+
+// CancelMsg is sent when the palette is closed without a pick
+type CancelMsg struct {
+	ID string
+}
+
+func (p *Palette) IsActive() bool {
+	return p.active
+}
+
+func (p *Palette) ID() string {
+	return p.id
+}
+
+// Open shows items under a title, id comes back in SelectMsg and CancelMsg
+func (p *Palette) Open(id, title string, items []Item) tea.Cmd {
+	p.id = id
+	p.title = title
+	p.items = items
+	p.active = true
+	p.input.SetValue("")
+	p.input.Focus()
+	p.filter()
+	return textinput.Blink
+}
+
+func (p *Palette) Close() {
+	p.active = false
+	p.input.Blur()
+}
+
+func (p *Palette) filter() {
+	query := strings.ToLower(p.input.Value())
+	p.matches = make([]Item, 0, len(p.items))
+	for _, item := range p.items {
+		if strings.Contains(strings.ToLower(item.Title+" "+item.Detail), query) {
+			p.matches = append(p.matches, item)
+		}
+	}
+	p.selectedIndex = 0
+}
+
+func (p *Palette) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+		p.height = msg.Height
+		p.input.Width = msg.Width - 4
+		return p, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "ctrl+c", "ctrl+q":
+			p.Close()
+			id := p.id
+			return p, func() tea.Msg { return CancelMsg{ID: id} }
+
+		case "enter":
+			if p.selectedIndex < len(p.matches) {
+				item := p.matches[p.selectedIndex]
+				id := p.id
+				p.Close()
+				return p, func() tea.Msg { return SelectMsg{ID: id, Item: item} }
+			}
+			return p, nil
+
+		case "up", "ctrl+k":
+			if p.selectedIndex > 0 {
+				p.selectedIndex--
+			}
+			return p, nil
+
+		case "down", "ctrl+j":
+			if p.selectedIndex < len(p.matches)-1 {
+				p.selectedIndex++
+			}
+			return p, nil
+		}
+
+		var cmd tea.Cmd
+		oldValue := p.input.Value()
+		p.input, cmd = p.input.Update(msg)
+		if p.input.Value() != oldValue {
+			p.filter()
+		}
+		return p, cmd
+	}
+
+	return p, nil
+}
+
+func (p *Palette) Init() tea.Cmd {
+	return nil
+}
+
+func (p *Palette) View() string {
+	if !p.active {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Padding(0, 1).Render(p.title))
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Padding(0, 1).Width(p.width - 4).Render(p.input.View()))
+	b.WriteString("\n")
+
+	if len(p.matches) == 0 {
+		b.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("240")).
+			Italic(true).
+			Padding(0, 2).
+			Render("Nothing here"))
+	}
+
+	maxVisible := max(1, p.height-7)
+	startIdx := 0
+	if p.selectedIndex >= maxVisible {
+		startIdx = p.selectedIndex - maxVisible + 1
+	}
+	endIdx := min(startIdx+maxVisible, len(p.matches))
+
+	for i := startIdx; i < endIdx; i++ {
+		item := p.matches[i]
+		lineStyle := lipgloss.NewStyle().Padding(0, 2)
+		line := item.Title
+		if i == p.selectedIndex {
+			lineStyle = lineStyle.
+				Background(lipgloss.Color("237")).
+				Foreground(styles.Highlight).
+				Bold(true)
+			if item.Detail != "" {
+				line += "   " + item.Detail
+			}
+		} else if item.Detail != "" {
+			line += lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Render("   " + item.Detail)
+		}
+		b.WriteString(lineStyle.Render(line))
+		b.WriteString("\n")
+	}
+
+	return lipgloss.NewStyle().
+		Width(p.width).
+		Height(p.height).
+		Padding(1, 2).
+		Render(b.String())
+}
@@ -6,6 +6,7 @@ package search
 import (
 	"strings"
 
+	"mend/internal/filesystem"
 	"mend/internal/search"
 	"mend/styles"
 
@@ -194,10 +195,13 @@ func (v *SearchView) View() string {
 				}
 				path = result.RelativePath + "/"
 			} else {
+				kind := filesystem.KindOf(result.Path)
 				if isSelected {
-					icon = styles.FileIcon
-				} else {
+					icon = styles.KindIcon(styles.Kind(kind.String()))
+				} else if kind == filesystem.KindNote {
 					icon = lipgloss.NewStyle().Foreground(styles.FileGreen).Render(styles.FileIcon)
+				} else {
+					icon = lipgloss.NewStyle().Foreground(styles.AttachmentGray).Render(styles.KindIcon(styles.Kind(kind.String())))
 				}
 				path = result.RelativePath
 				if result.Snippet != "" && !isSelected {
@@ -208,6 +212,10 @@ func (v *SearchView) View() string {
 				}
 			}
 
+			if result.RootName != "" {
+				// several roots open, say which one it's from
+				path = lipgloss.NewStyle().Faint(!isSelected).Render(result.RootName+": ") + path
+			}
 			line := icon + " " + path
 			b.WriteString(lineStyle.Render(line))
 			b.WriteString("\n")
@@ -0,0 +1,71 @@
+package versions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	"mend/internal/filesystem"
+)
+
+// drafts of notes being edited, kept next to the versions
+const recoveryDirName = "recovery"
+
+// Draft is unsaved editor content left behind by a session that ended early
+type Draft struct {
+	Content string
+	Time    time.Time
+}
+
+// SaveDraft keeps what the editor has for a note until it's saved or dropped
+func (s *Store) SaveDraft(path string, content []byte) error {
+	if s == nil {
+		return nil
+	}
+	draft, ok := s.dir(path, recoveryDirName)
+	if !ok {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(draft), 0755); err != nil {
+		return err
+	}
+	return filesystem.WriteFileAtomic(draft, content)
+}
+
+// Draft gives the draft of a note when it's newer than the note and differs from it
+func (s *Store) Draft(path string) (Draft, bool) {
+	if s == nil {
+		return Draft{}, false
+	}
+	draft, ok := s.dir(path, recoveryDirName)
+	if !ok {
+		return Draft{}, false
+	}
+	info, err := os.Stat(draft)
+	if err != nil {
+		return Draft{}, false
+	}
+	noteInfo, err := os.Stat(path)
+	if err != nil || !info.ModTime().After(noteInfo.ModTime()) {
+		return Draft{}, false
+	}
+	content, err := os.ReadFile(draft)
+	if err != nil {
+		return Draft{}, false
+	}
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, content) {
+		return Draft{}, false
+	}
+	return Draft{Content: string(content), Time: info.ModTime()}, true
+}
+
+// DropDraft forgets the draft of a note, once it's saved or thrown away
+func (s *Store) DropDraft(path string) {
+	if s == nil {
+		return
+	}
+	if draft, ok := s.dir(path, recoveryDirName); ok {
+		os.Remove(draft)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+old versions of notes. before a save overwrites a note its previous content is
+copied to .mend/versions in the note's root, under the same relative path, one
+file per save named by the time it was taken. only the last few are kept.
+*/
+
+package versions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"mend/internal/filesystem"
+	"mend/internal/session"
+)
+
+const dirName = "versions"
+
+// names of version files, sortable as text
+const timeFormat = "20060102T150405.000000000Z"
+
+// Version is a saved copy of a note
+type Version struct {
+	Path string // the copy, not the note
+	Time time.Time
+	Size int64
+}
+
+// Store keeps versions for the notes in a set of roots, a nil store keeps nothing
+type Store struct {
+	roots []string
+	keep  int
+}
+
+// NewStore keeps the last keep versions of every note, none when keep is 0
+func NewStore(roots []string, keep int) *Store {
+	return &Store{roots: roots, keep: keep}
+}
+
+// dir is where the copies of a note go in the named folder of its root's dot
+// folder, false for a note outside the roots
+func (s *Store) dir(path, name string) (string, bool) {
+	for _, root := range s.roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		return filepath.Join(session.Dir(root), name, rel), true
+	}
+	return "", false
+}
+
+// Save keeps data as the newest version of the note at path, unless it's
+// the same as the newest one already kept. old versions past the limit go.
+func (s *Store) Save(path string, data []byte) error {
+	if s == nil || s.keep <= 0 {
+		return nil
+	}
+	dir, ok := s.dir(path, dirName)
+	if !ok {
+		return nil
+	}
+	existing := s.List(path)
+	if len(existing) > 0 {
+		if newest, err := os.ReadFile(existing[0].Path); err == nil && bytes.Equal(newest, data) {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format(timeFormat) + filepath.Ext(path)
+	if err := filesystem.WriteFileAtomic(filepath.Join(dir, name), data); err != nil {
+		return err
+	}
+	for _, old := range s.List(path)[min(s.keep, len(existing)+1):] {
+		os.Remove(old.Path)
+	}
+	return nil
+}
+
+// List gives the versions of a note, newest first
+func (s *Store) List(path string) []Version {
+	if s == nil {
+		return nil
+	}
+	dir, ok := s.dir(path, dirName)
+	if !ok {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	versions := make([]Version, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		at, err := time.Parse(timeFormat, strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+		if err != nil {
+			continue // not one of ours
+		}
+		version := Version{Path: filepath.Join(dir, entry.Name()), Time: at}
+		if info, err := entry.Info(); err == nil {
+			version.Size = info.Size()
+		}
+		versions = append(versions, version)
+	}
+	slices.SortFunc(versions, func(a, b Version) int { return b.Time.Compare(a.Time) })
+	return versions
+}
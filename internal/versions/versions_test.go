@@ -0,0 +1,41 @@
+package versions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tests keeping, skipping duplicates and pruning old versions
+func TestStore(t *testing.T) {
+	root, err := os.MkdirTemp("", "mend_versions_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, "sub", "a.md")
+
+	store := NewStore([]string{root}, 2)
+	for _, content := range []string{"one", "two", "two", "three"} {
+		if err := store.Save(path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	found := store.List(path)
+	if len(found) != 2 {
+		t.Fatalf("expected 2 versions kept, got %d", len(found))
+	}
+	if data, _ := os.ReadFile(found[0].Path); string(data) != "three" {
+		t.Errorf("expected the newest first, got %q", data)
+	}
+	if filepath.Dir(found[0].Path) != filepath.Join(root, ".mend", "versions", "sub", "a.md") {
+		t.Errorf("unexpected version folder %s", filepath.Dir(found[0].Path))
+	}
+
+	if err := store.Save(filepath.Join(os.TempDir(), "elsewhere.md"), []byte("x")); err != nil {
+		t.Error("expected notes outside the roots to be skipped")
+	}
+	if err := NewStore([]string{root}, 0).Save(path, []byte("four")); err != nil || len(store.List(path)) != 2 {
+		t.Error("expected nothing kept with versions turned off")
+	}
+}
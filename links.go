@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"mend/internal/frontmatter"
+	"mend/internal/links"
+	"mend/internal/sections"
+	"mend/internal/ui/fstree"
+	"mend/internal/ui/note"
+	"mend/internal/ui/palette"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	linksPaletteID   = "links"
+	brokenPaletteID  = "broken-links"
+	rewritePaletteID = "rewrite-links"
+)
+
+// every link in the vault that goes nowhere
+type brokenLinksMsg struct {
+	items []palette.Item
+}
+
+// opens where a link points, at its heading if it has one
+func (m *model) followLink(from string, link links.Link) tea.Cmd {
+	path, ok := m.links.Resolve(link.Target, from)
+	if !ok {
+		m.tree.ErrMsg = "no note for [[" + link.Text() + "]]"
+		return nil
+	}
+	return m.openAtHeading(path, link.Heading)
+}
+
+func (m *model) openAtHeading(path, heading string) tea.Cmd {
+	section := 0
+	if heading != "" {
+		section = headingSection(path, heading)
+	}
+	if path == m.noteView.Path {
+		return func() tea.Msg { return note.LoadNoteMsg{Path: path, Section: section, Force: true} }
+	}
+	m.tree.RestoreSelection(path)
+	return m.openNote(path, section)
+}
+
+func headingSection(path, heading string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	return sections.Index(sections.Parse(data), heading)
+}
+
+func (m *model) openLinkPicker(msg note.LinkPickerMsg) tea.Cmd {
+	if len(msg.Links) == 0 {
+		m.tree.ErrMsg = "no links in this note"
+		return nil
+	}
+	items := make([]palette.Item, 0, len(msg.Links))
+	for _, link := range msg.Links {
+		item := palette.Item{Title: "[[" + link.Text() + "]]", Detail: "unresolved"}
+		if link.Label != "" {
+			item.Title = link.Label + " [[" + link.Text() + "]]"
+		}
+		if path, ok := m.links.Resolve(link.Target, msg.From); ok {
+			item.Detail = m.tree.RelPath(path)
+			item.Value = path + "\n" + link.Heading
+		}
+		items = append(items, item)
+	}
+	_, sizeCmd := m.palette.Update(tea.WindowSizeMsg{Width: m.terminalWidth, Height: m.terminalHeight})
+	return tea.Batch(sizeCmd, m.palette.Open(linksPaletteID, "Links", items))
+}
+
+// picked from the link picker, value is the path and heading
+func (m *model) openPickedLink(value string) tea.Cmd {
+	if value == "" {
+		m.tree.ErrMsg = "link does not resolve to a note"
+		return nil
+	}
+	path, heading, _ := strings.Cut(value, "\n")
+	return m.openAtHeading(path, heading)
+}
+
+// scans every note for links that go nowhere, off the ui loop
+func (m *model) findBrokenLinks() tea.Cmd {
+	if m.links == nil {
+		m.tree.ErrMsg = "links are still being indexed"
+		return nil
+	}
+	index := m.links
+	paths := m.tree.NotePaths()
+	relPath := make(map[string]string, len(paths))
+	for _, path := range paths {
+		relPath[path] = m.tree.RelPath(path)
+	}
+	return func() tea.Msg {
+		items := make([]palette.Item, 0)
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			for _, link := range links.Parse(data) {
+				if _, ok := index.Resolve(link.Target, path); !ok {
+					items = append(items, palette.Item{
+						Title:  "[[" + link.Text() + "]]",
+						Detail: relPath[path],
+						Value:  path,
+					})
+				}
+			}
+		}
+		return brokenLinksMsg{items: items}
+	}
+}
+
+func (m *model) openBrokenLinks(items []palette.Item) tea.Cmd {
+	title := "Broken links"
+	if len(items) == 0 {
+		title = "Broken links: none"
+	}
+	_, sizeCmd := m.palette.Update(tea.WindowSizeMsg{Width: m.terminalWidth, Height: m.terminalHeight})
+	return tea.Batch(sizeCmd, m.palette.Open(brokenPaletteID, title, items))
+}
+
+// hands the note view the notes linking to the open one
+func (m *model) updateBacklinks() {
+	if m.tree == nil || m.graph == nil {
+		return
+	}
+	refs := m.graph.Backlinks(m.noteView.Path)
+	backlinks := make([]note.Backlink, 0, len(refs))
+	for _, ref := range refs {
+		backlinks = append(backlinks, note.Backlink{
+			Path:    ref.From,
+			Name:    strings.TrimSuffix(m.tree.RelPath(ref.From), ".md"),
+			Snippet: ref.Snippet,
+		})
+	}
+	m.noteView.SetBacklinks(backlinks)
+}
+
+// opens a linking note on the section the link is in
+func (m *model) openBacklink(backlink note.Backlink) tea.Cmd {
+	section := 0
+	if data, err := os.ReadFile(backlink.Path); err == nil {
+		section = sections.Containing(sections.Parse(data), backlink.Snippet)
+	}
+	m.tree.RestoreSelection(backlink.Path)
+	return m.openNote(backlink.Path, section)
+}
+
+// link rewrites worked out after a move, waiting for the preview to be confirmed
+type rewritePlanMsg struct {
+	edits []links.Edit
+}
+
+// the rewrite is done, err says why nothing was changed
+type rewriteDoneMsg struct {
+	edits []links.Edit
+	err   error
+}
+
+// works out which links the moves broke. the current index still knows where
+// the links pointed, a fresh one is built for the tree as it is now.
+func (m *model) planLinkRewrites(moves []fstree.Move) tea.Cmd {
+	if m.tree == nil || m.links == nil || len(moves) == 0 {
+		return nil
+	}
+	before := m.links
+	roots := m.tree.RootPaths()
+	paths := m.tree.NotePaths()
+	noteMoves := make([]links.Move, 0)
+	for _, path := range paths {
+		for _, mv := range moves {
+			if from, ok := movedFrom(path, mv); ok {
+				noteMoves = append(noteMoves, links.Move{From: from, To: path})
+				break
+			}
+		}
+	}
+	if len(noteMoves) == 0 {
+		return nil // only attachments or empty folders moved
+	}
+	return func() tea.Msg {
+		notes := make([]links.Note, 0, len(paths))
+		for _, path := range paths {
+			notes = append(notes, links.Note{Path: path, Aliases: frontmatter.Read(path).Aliases})
+		}
+		after := links.NewIndex(roots, notes)
+		return rewritePlanMsg{edits: links.PlanRewrites(before, after, noteMoves, paths)}
+	}
+}
+
+// where a path was before a move, the reverse of movedPath
+func movedFrom(path string, mv fstree.Move) (string, bool) {
+	back, ok := movedPath(path, fstree.Move{From: mv.To, To: mv.From})
+	return back, ok
+}
+
+// lists the notes that would change, enter on any of them rewrites them all
+func (m *model) previewRewrites(edits []links.Edit) tea.Cmd {
+	m.pendingRewrite = edits
+	items := make([]palette.Item, 0, len(edits))
+	for _, edit := range edits {
+		items = append(items, palette.Item{
+			Title:  m.tree.RelPath(edit.Path),
+			Detail: strings.Join(edit.Changes, ", "),
+		})
+	}
+	title := fmt.Sprintf("Update links in %d notes? enter to rewrite, esc to leave them", len(edits))
+	_, sizeCmd := m.palette.Update(tea.WindowSizeMsg{Width: m.terminalWidth, Height: m.terminalHeight})
+	return tea.Batch(sizeCmd, m.palette.Open(rewritePaletteID, title, items))
+}
+
+func (m *model) applyRewrites() tea.Cmd {
+	edits := m.pendingRewrite
+	m.pendingRewrite = nil
+	if len(edits) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		return rewriteDoneMsg{edits: edits, err: links.Apply(edits)}
+	}
+}
+
+func (m *model) rewriteDone(msg rewriteDoneMsg) tea.Cmd {
+	if msg.err != nil {
+		m.tree.ErrMsg = msg.err.Error()
+		return nil
+	}
+	paths := make([]string, 0, len(msg.edits))
+	for _, edit := range msg.edits {
+		paths = append(paths, edit.Path)
+	}
+	cmds := []tea.Cmd{m.reindex(), m.afterChange(fmt.Sprintf("Update links in %d notes", len(msg.edits)), paths...)}
+	for _, edit := range msg.edits {
+		if edit.Path == m.noteView.Path {
+			path, section := edit.Path, m.noteView.CurrentSection()
+			cmds = append(cmds, func() tea.Msg { return note.LoadNoteMsg{Path: path, Section: section, Force: true} })
+		}
+	}
+	return tea.Batch(cmds...)
+}
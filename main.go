@@ -3,12 +3,23 @@ package main
 import (
 	"fmt"
 	"os"
-	"os/exec"
-
+	"path/filepath"
+	"time"
+
+	"mend/internal/config"
+	"mend/internal/filesystem"
+	"mend/internal/git"
+	"mend/internal/history"
+	"mend/internal/ignore"
+	"mend/internal/links"
+	"mend/internal/review"
 	"mend/internal/search"
+	"mend/internal/session"
 	"mend/internal/ui/fstree"
 	"mend/internal/ui/note"
+	"mend/internal/ui/palette"
 	uisearch "mend/internal/ui/search"
+	"mend/internal/versions"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -21,13 +32,14 @@ Quick notes to self:
 */
 
 type model struct {
+	config            *config.Config
 	width             int
 	terminalWidth     int
 	terminalHeight    int
 	fsTreeWidth       int
 	noteViewWidth     int
 	tree              *fstree.FsTree
-	rootPath          string // path to load the tree from
+	rootPaths         []string // roots to load the tree from, cwd if empty
 	loading           bool
 	noteView          *note.NoteView
 	isDragging        bool
@@ -43,24 +55,47 @@ type model struct {
 	searchEngine *search.SearchEngine
 	searchView   *uisearch.SearchView
 	searchMode   bool
+	// review of a fixed set of notes, nil when not reviewing
+	review *review.Session
+	// the tree, index and watcher all skip the same paths
+	ignore *ignore.Matcher
+	// external changes on disk
+	watcher      *filesystem.Watcher
+	reindexToken int // debounce for reindexing after a burst of fs events
+	// recently opened notes, back/forward
+	history *history.History
+	palette *palette.Palette
+	links   *links.Index // nil until the first index is built
+	graph   *links.Graph
+	// link rewrites waiting for the preview to be confirmed
+	pendingRewrite []links.Edit
+	// versions of the open note listed in the palette
+	versionList []versions.Version
+	// roots that are git repos, empty if none
+	repos []*git.Repo
 }
 
-func NewModel(rootPath string) *model {
+func NewModel(rootPaths []string, cfg *config.Config) *model {
 	ti := textinput.New()
 	ti.CharLimit = 156
 	ti.Width = 30
 
 	searchEngine := search.NewSearchEngine()
+	noteView := note.NewNoteView()
+	noteView.SetAutosave(time.Duration(cfg.AutosaveIdle)*time.Second, time.Duration(cfg.AutosaveInterval)*time.Second, cfg.AutosaveToNote)
 
 	return &model{
-		rootPath:      rootPath,
+		rootPaths:     rootPaths,
+		config:        cfg,
 		loading:       true,
-		noteView:      note.NewNoteView(),
+		noteView:      noteView,
 		showStatusBar: false,
 		showSidebar:   true,
 		textInput:     ti,
 		searchEngine:  searchEngine,
 		searchView:    uisearch.NewSearchView(searchEngine),
+		history:       history.New(),
+		palette:       palette.New(),
 	}
 }
 
@@ -68,23 +103,56 @@ func NewModel(rootPath string) *model {
 // these need to be on the "model" ( duck typing "implements" interface )
 
 type treeLoadedMsg struct {
-	tree *fstree.FsTree
+	tree    *fstree.FsTree
+	watcher *filesystem.Watcher // nil if the root could not be watched, the app works without it
+	session *session.State      // nil on the first run in a root
+	ignore  *ignore.Matcher
+	repos   []*git.Repo
+}
+
+type reindexMsg struct {
+	token int
 }
 
-func (m *model) loadTreeCmd(path string) tea.Cmd {
+// how long the fs has to be quiet before the search index is rebuilt
+const reindexDelay = 500 * time.Millisecond
+
+// waits for the next change on disk, has to be re-issued after every event
+func waitForFsEvent(w *filesystem.Watcher) tea.Cmd {
 	return func() tea.Msg {
-		var targetPath string
-		if path == "" {
+		ev, ok := w.Next()
+		if !ok {
+			return nil
+		}
+		return fstree.ExternalChangeMsg{Event: ev}
+	}
+}
+
+func (m *model) loadTreeCmd(paths []string) tea.Cmd {
+	return func() tea.Msg {
+		var targetPaths []string
+		if len(paths) == 0 {
 			cwd, err := os.Getwd()
 			if err != nil {
 				fmt.Println("Error getting cwd:", err)
 				os.Exit(1)
 			}
-			targetPath = cwd
+			targetPaths = []string{cwd}
 		} else {
-			targetPath = path
+			targetPaths = paths
+		}
+		matcher := ignore.NewWorkspace(targetPaths, m.config.HonorGitignore)
+		tree := fstree.NewWorkspaceTree(targetPaths, fsTreeStartOffset, matcher)
+		tree.SetNotesOnly(m.config.ShowOnlyNotes)
+		watcher, _ := filesystem.NewWorkspaceWatcher(targetPaths, matcher.Ignored)
+		state, _ := session.LoadWorkspace(targetPaths) // a broken state file just means a fresh start
+		return treeLoadedMsg{
+			tree:    tree,
+			watcher: watcher,
+			session: state,
+			ignore:  matcher,
+			repos:   openRepos(targetPaths),
 		}
-		return treeLoadedMsg{tree: fstree.NewFsTree(targetPath, fsTreeStartOffset)}
 	}
 }
 
@@ -103,12 +171,20 @@ func (m *model) layout(width, height int) {
 	}
 
 	h := height
-	if m.showStatusBar || m.inputMode {
+	if m.showStatusBar || m.inputMode || m.treeFiltering() {
 		h -= statusBarHeight
 	}
 	m.contentHeight = max(0, h)
 }
 
+func (m *model) reindex() tea.Cmd {
+	return search.StartIndexing(m.searchEngine, m.tree.RootPaths(), m.ignore)
+}
+
+func (m *model) treeFiltering() bool {
+	return m.tree != nil && m.tree.IsFiltering()
+}
+
 func (m *model) resizeChildren() tea.Cmd {
 	var cmds []tea.Cmd
 	if m.tree != nil {
@@ -127,7 +203,7 @@ func (m *model) resizeChildren() tea.Cmd {
 }
 
 func (m *model) Init() tea.Cmd {
-	return m.loadTreeCmd(m.rootPath)
+	return m.loadTreeCmd(m.rootPaths)
 }
 
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -140,16 +216,49 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case treeLoadedMsg:
 		m.tree = msg.tree
+		m.ignore = msg.ignore
+		m.repos = msg.repos
 		m.loading = false
 		m.fsTreeWidth = m.tree.ContentWidth()
-		m.fsTreeWidth, m.noteViewWidth = getUpdatedWindowSizes(m.terminalWidth, m.fsTreeWidth, m.tree.ContentWidth())
-		_, cmd := m.tree.Update(tea.WindowSizeMsg{
-			Height: m.contentHeight,
-			Width:  m.fsTreeWidth,
-		})
+		m.noteView.SetVersions(versions.NewStore(m.tree.RootPaths(), m.config.Versions))
+		restoreCmd := m.restoreSession(msg.session)
+		m.layout(m.terminalWidth, m.terminalHeight)
 		// Start background indexing
-		indexCmd := search.StartIndexing(m.searchEngine, m.tree.Root.Path)
-		return m, tea.Batch(cmd, indexCmd)
+		indexCmd := m.reindex()
+		cmds := []tea.Cmd{m.resizeChildren(), indexCmd, restoreCmd, m.refreshGit()}
+		if msg.watcher != nil {
+			m.watcher = msg.watcher
+			cmds = append(cmds, waitForFsEvent(m.watcher))
+		}
+		return m, tea.Batch(cmds...)
+
+	case fstree.ExternalChangeMsg:
+		m.reindexToken++
+		token := m.reindexToken
+		cmds := []tea.Cmd{
+			waitForFsEvent(m.watcher),
+			tea.Tick(reindexDelay, func(time.Time) tea.Msg { return reindexMsg{token: token} }),
+		}
+		if m.tree != nil {
+			_, cmd := m.tree.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+		return m, tea.Batch(cmds...)
+
+	case reindexMsg:
+		if msg.token != m.reindexToken || m.tree == nil {
+			return m, nil // more changes came in since, a later tick will do it
+		}
+		return m, tea.Batch(m.reindex(), m.refreshGit())
+
+	case gitStatusMsg:
+		if m.tree != nil {
+			m.tree.SetGitStatus(msg.statuses)
+			if msg.err != nil {
+				m.tree.ErrMsg = msg.err.Error()
+			}
+		}
+		return m, nil
 
 	case uisearch.SearchSelectMsg:
 		m.searchMode = false
@@ -159,16 +268,115 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 		}
-		_, cmd := m.noteView.Update(note.LoadNoteMsg{Path: msg.Path})
-		return m, cmd
+		if m.tree != nil {
+			m.tree.RestoreSelection(msg.Path)
+		}
+		return m, m.openNote(msg.Path, 0)
 
 	case uisearch.SearchCancelMsg:
 		m.searchMode = false
 		return m, nil
 
 	case fstree.NodeSelectedMsg:
-		// Forward node selection to noteView
-		_, cmd := m.noteView.Update(note.LoadNoteMsg{Path: msg.Path})
+		return m, m.openNote(msg.Path, 0)
+
+	case palette.SelectMsg:
+		switch msg.ID {
+		case recentPaletteID:
+			return m, m.openRecent(msg.Item.Value)
+		case linksPaletteID:
+			return m, m.openPickedLink(msg.Item.Value)
+		case brokenPaletteID:
+			return m, m.openAtHeading(msg.Item.Value, "")
+		case rewritePaletteID:
+			return m, m.applyRewrites()
+		case versionsPaletteID:
+			return m, m.previewVersion(msg.Item.Value)
+		}
+		return m, nil
+
+	case palette.CancelMsg:
+		if msg.ID == rewritePaletteID {
+			m.pendingRewrite = nil
+		}
+		return m, nil
+
+	case rewritePlanMsg:
+		if len(msg.edits) == 0 {
+			return m, nil
+		}
+		return m, m.previewRewrites(msg.edits)
+
+	case rewriteDoneMsg:
+		return m, m.rewriteDone(msg)
+
+	case search.IndexedMsg:
+		if !m.searchEngine.Apply(msg) {
+			return m, nil // a newer pass is on its way
+		}
+		m.links = msg.Links
+		m.graph = msg.Graph
+		m.noteView.SetLinkIndex(msg.Links)
+		m.updateBacklinks()
+		return m, nil
+
+	case note.OpenBacklinkMsg:
+		if m.tree == nil {
+			return m, nil
+		}
+		return m, m.openBacklink(msg.Backlink)
+
+	case brokenLinksMsg:
+		return m, m.openBrokenLinks(msg.items)
+
+	case note.FollowLinkMsg:
+		if m.tree == nil || m.noteView.IsEditing() {
+			return m, nil // saving before following ran into a conflict
+		}
+		return m, m.followLink(msg.From, msg.Link)
+
+	case note.LinkPickerMsg:
+		if m.tree == nil {
+			return m, nil
+		}
+		return m, m.openLinkPicker(msg)
+
+	case fstree.StartReviewMsg:
+		paths := review.Reviewable(msg.Paths)
+		if len(paths) == 0 {
+			m.tree.ErrMsg = "selected notes are excluded from review (srs: false)"
+			return m, nil
+		}
+		m.review = review.NewSession(paths)
+		return m, m.openReviewNote()
+
+	case note.EndOfNoteMsg:
+		if m.review == nil || msg.Path != m.review.Current() {
+			return m, nil
+		}
+		m.review.Next()
+		return m, m.openReviewNote()
+
+	case fstree.NodesMovedMsg:
+		// the open note may have moved with its folder
+		var cmd tea.Cmd
+		for _, mv := range msg.Moves {
+			m.history.Moved(mv.From, mv.To)
+			if newPath, ok := movedPath(m.noteView.Path, mv); ok {
+				section := m.noteView.CurrentSection()
+				cmd = func() tea.Msg { return note.LoadNoteMsg{Path: newPath, Section: section} }
+			}
+		}
+		return m, tea.Batch(cmd, m.planLinkRewrites(msg.Moves), m.reindex())
+
+	case editorDoneMsg:
+		return m, m.editorDone(msg)
+
+	case note.ShowVersionsMsg:
+		return m, m.openVersions(msg)
+
+	case note.ConflictMsg, note.AutosaveMsg, note.AutosavedMsg:
+		_, cmd := m.noteView.Update(msg)
 		return m, cmd
 
 	case note.LoadNoteMsg:
@@ -181,12 +389,18 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case note.LoadedNote:
 		// Forward loaded note to noteView
 		_, cmd := m.noteView.Update(msg)
+		m.updateBacklinks()
+		if msg.Saved && msg.Err == nil {
+			m.tree.RefreshTags() // the note may have gained or lost some
+			// links in and out of it may have changed, the watcher doesn't see writes
+			return m, tea.Batch(cmd, m.reindex(), m.afterChange("Update "+m.tree.RelPath(m.noteView.Path), m.noteView.Path))
+		}
 		return m, cmd
 
 	case fstree.PerformActionMsg:
 		if m.tree != nil {
 			_, cmd := m.tree.Update(msg)
-			return m, cmd
+			return m, tea.Batch(cmd, m.afterChange(actionCommitMessage(msg), m.tree.TakeChanged()...))
 		}
 
 	case fstree.ContentSizeChangeMsg:
@@ -207,6 +421,19 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.textInput.Placeholder = "New Folder Name"
 		case fstree.ActionNewRoot:
 			m.textInput.Placeholder = "New Root Folder Name"
+		case fstree.ActionMove:
+			if m.tree != nil && m.tree.IsMultiRoot() {
+				m.textInput.Placeholder = "Move to folder (starting with the root's name)"
+			} else {
+				m.textInput.Placeholder = "Move to folder (relative to root, . for root)"
+			}
+		case fstree.ActionTag:
+			m.textInput.Placeholder = "Tag"
+		case fstree.ActionRename:
+			m.textInput.Placeholder = "New name"
+			if m.tree != nil && m.tree.SelectedNode != nil {
+				m.textInput.SetValue(filepath.Base(m.tree.SelectedNode.Path))
+			}
 		}
 		m.layout(m.terminalWidth, m.terminalHeight) // recalc layout for status bar area
 		return m, m.resizeChildren()
@@ -241,20 +468,41 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
+		if m.palette.IsActive() {
+			_, cmd := m.palette.Update(msg)
+			return m, cmd
+		}
+
 		// search mode, forward all keys to search view
 		if m.searchMode {
 			_, cmd := m.searchView.Update(msg)
 			return m, cmd
 		}
 
+		// filtering the tree takes all keys until it's accepted or cancelled
+		if m.treeFiltering() {
+			_, cmd := m.tree.Update(msg)
+			if !m.tree.IsFiltering() {
+				m.layout(m.terminalWidth, m.terminalHeight) // status bar goes away
+				return m, tea.Batch(cmd, m.resizeChildren())
+			}
+			return m, cmd
+		}
+
 		// If editing, forward all keys to noteView and ignore global bindings
-		if m.noteView.IsEditing() {
+		if m.noteView.IsEditing() || m.noteView.HoldsKeys() {
 			_, cmd := m.noteView.Update(msg)
 			return m, cmd
 		}
 
 		switch msg.String() {
+		case "esc":
+			m.review = nil // tree still gets it to clear marks
 		case "q", "ctrl+c":
+			m.saveSession()
+			if m.watcher != nil {
+				m.watcher.Close()
+			}
 			return m, tea.Quit
 		case "ctrl+f", "ctrl+p":
 			m.searchMode = true
@@ -264,6 +512,25 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			})
 			activateCmd := m.searchView.Activate()
 			return m, tea.Batch(cmd, activateCmd)
+		case "ctrl+r":
+			if m.tree != nil {
+				return m, m.openRecentPalette()
+			}
+		case "L": // every link in the vault that goes nowhere
+			if m.tree != nil {
+				return m, m.findBrokenLinks()
+			}
+		case "[", "alt+left":
+			return m, m.goBack()
+		case "]", "alt+right":
+			return m, m.goForward()
+		case "r": // reveal the open note in the tree
+			if m.tree != nil && m.noteView.Path != "" {
+				if !m.tree.Reveal(m.noteView.Path) {
+					m.tree.ErrMsg = "open note is not shown in the tree"
+				}
+				return m, nil
+			}
 		case "ctrl+b":
 			m.showSidebar = !m.showSidebar
 			m.layout(m.terminalWidth, m.terminalHeight)
@@ -274,21 +541,38 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.resizeChildren()
 		case "o":
 			if m.tree != nil && m.tree.SelectedNode != nil && m.tree.SelectedNode.Type == fstree.FileNode {
-				c := exec.Command("micro", m.tree.SelectedNode.Path)
-				c.Stdin = os.Stdin
-				c.Stdout = os.Stdout
-				c.Stderr = os.Stderr
-				return m, tea.ExecProcess(c, func(err error) tea.Msg {
-					// mouse loses focus so this is neededd
-					return note.LoadNoteMsg{Path: m.tree.SelectedNode.Path, Force: true}
-				})
+				if m.tree.SelectedNode.Kind() != filesystem.KindNote {
+					// images, pdfs etc. go to whatever the system opens them with
+					if err := filesystem.OpenExternally(m.tree.SelectedNode.Path); err != nil {
+						m.tree.ErrMsg = err.Error()
+					}
+					return m, nil
+				}
+				return m, m.openInEditor(m.tree.SelectedNode.Path)
 			}
+		case "/":
+			if m.tree == nil || !m.showSidebar {
+				return m, nil // nothing to filter on screen
+			}
+			_, cmd := m.tree.Update(msg)
+			m.layout(m.terminalWidth, m.terminalHeight) // filter shows in the status bar
+			return m, tea.Batch(cmd, m.resizeChildren())
 		case "delete":
 			// Forward delete to fstree if focused (implied focus on tree for now when not editing)
 			if m.tree != nil {
 				_, cmd := m.tree.Update(msg)
-				return m, cmd
+				return m, tea.Batch(cmd, m.afterChange("Delete notes", m.tree.TakeChanged()...))
 			}
+		case "D":
+			if m.tree == nil || !m.showSidebar {
+				return m, nil // the node to copy isn't on screen
+			}
+			_, cmd := m.tree.Update(msg)
+			message := "Duplicate notes"
+			if m.tree.SelectedNode != nil {
+				message = "Add " + m.tree.RelPath(m.tree.SelectedNode.Path) // the copy
+			}
+			return m, tea.Batch(cmd, m.afterChange(message, m.tree.TakeChanged()...))
 		}
 
 		var cmds []tea.Cmd
@@ -357,6 +641,9 @@ func (m model) View() string {
 	if m.searchMode {
 		return m.searchView.View()
 	}
+	if m.palette.IsActive() {
+		return m.palette.View()
+	}
 
 	tree := m.tree.View()
 	tree = lipgloss.NewStyle().
@@ -400,15 +687,30 @@ func (m model) View() string {
 		full = notes
 	}
 
-	if !m.showStatusBar && !m.inputMode {
+	if !m.showStatusBar && !m.inputMode && !m.treeFiltering() {
 		return full
 	}
 
 	statusContent := ""
 	if m.inputMode {
 		statusContent = m.textInput.View()
+	} else if m.treeFiltering() {
+		statusContent = "/" + m.tree.FilterQuery() + lipgloss.NewStyle().Faint(true).Render("  enter to keep, esc to cancel")
 	} else if m.tree != nil && m.tree.ErrMsg != "" {
 		statusContent = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.tree.ErrMsg)
+	} else if m.tree != nil {
+		info := "sort: " + m.tree.SortMode().String()
+		if m.tree.IsTagsMode() {
+			info = "tags view"
+		}
+		if m.review != nil {
+			pos, total := m.review.Progress()
+			info += fmt.Sprintf("  review %d/%d", pos, total)
+			if deck := m.noteView.Meta().Deck; deck != "" {
+				info += "  deck: " + deck
+			}
+		}
+		statusContent = lipgloss.NewStyle().Faint(true).Render(info)
 	}
 
 	statusBar := lipgloss.NewStyle().
@@ -427,13 +729,27 @@ func (m model) View() string {
 // =================== bubbletea ui fns ===================
 
 func main() {
-	var rootPath string
-	if len(os.Args) > 1 {
-		rootPath = os.Args[1]
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Error reading config %s: %v\n", config.Path(), err)
+		os.Exit(1)
+	}
+	// roots on the command line win over the configured ones
+	rootPaths := os.Args[1:]
+	if len(rootPaths) == 0 {
+		rootPaths = cfg.RootPaths()
+	}
+	for i, path := range rootPaths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			fmt.Printf("Error resolving %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		rootPaths[i] = abs
 	}
-	// if rootPath is empty, createModel will use cwd
+	// if rootPaths is empty, createModel will use cwd
 	p := tea.NewProgram(
-		NewModel(rootPath),
+		NewModel(rootPaths, cfg),
 		tea.WithAltScreen(), // full screen tui
 		tea.WithMouseAllMotion(),
 	)
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"mend/internal/ui/fstree"
+	"mend/internal/ui/note"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// opens whatever the review is at, or ends the review when it ran out of notes
+func (m *model) openReviewNote() tea.Cmd {
+	path := m.review.Current()
+	if path == "" {
+		m.review = nil
+		return nil
+	}
+	if m.tree != nil {
+		m.tree.RestoreSelection(path)
+	}
+	return func() tea.Msg { return note.LoadNoteMsg{Path: path, Force: true} }
+}
+
+// where a path ended up after a move, also for paths inside a moved folder
+func movedPath(path string, mv fstree.Move) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	if path == mv.From {
+		return mv.To, true
+	}
+	prefix := mv.From + string(filepath.Separator)
+	if strings.HasPrefix(path, prefix) {
+		return filepath.Join(mv.To, strings.TrimPrefix(path, prefix)), true
+	}
+	return "", false
+}
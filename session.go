@@ -0,0 +1,105 @@
+package main
+
+import (
+	"mend/internal/history"
+	"mend/internal/session"
+	"mend/internal/ui/fstree"
+	"mend/internal/ui/note"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// snapshot of what should be restored next time these roots are opened
+func (m *model) sessionState() *session.State {
+	state := &session.State{
+		ExpandedPaths: make([]string, 0),
+		Pins:          make([]string, 0),
+		OpenNote:      m.tree.RelPath(m.noteView.Path),
+		SectionIndex:  m.noteView.CurrentSection(),
+		SidebarWidth:  m.fsTreeWidth,
+		ShowSidebar:   m.showSidebar,
+		ShowStatusBar: m.showStatusBar,
+		SortMode:      m.tree.SortMode().String(),
+	}
+	for _, p := range m.tree.ExpandedPaths() {
+		state.ExpandedPaths = append(state.ExpandedPaths, m.tree.RelPath(p))
+	}
+	for _, p := range m.tree.PinnedPaths() {
+		state.Pins = append(state.Pins, m.tree.RelPath(p))
+	}
+	if m.tree.SelectedNode != nil && !m.tree.IsTagsMode() { // tag folders aren't on disk
+		state.SelectedPath = m.tree.RelPath(m.tree.SelectedNode.Path)
+	}
+	// leaving counts as a visit so the section the note is on now is kept
+	if m.noteView.Path != "" {
+		m.history.Navigate(m.currentEntry(), m.currentEntry())
+	}
+	state.Recent = m.relEntries(m.history.Recent())
+	state.Back = m.relEntries(m.history.BackList())
+	state.Forward = m.relEntries(m.history.ForwardList())
+	return state
+}
+
+func (m *model) relEntries(entries []history.Entry) []history.Entry {
+	rel := make([]history.Entry, 0, len(entries))
+	for _, e := range entries {
+		rel = append(rel, history.Entry{Path: m.tree.RelPath(e.Path), Section: e.Section})
+	}
+	return rel
+}
+
+func (m *model) absEntries(entries []history.Entry) []history.Entry {
+	abs := make([]history.Entry, 0, len(entries))
+	for _, e := range entries {
+		abs = append(abs, history.Entry{Path: m.tree.AbsPath(e.Path), Section: e.Section})
+	}
+	return abs
+}
+
+func (m *model) saveSession() {
+	if m.tree == nil {
+		return
+	}
+	// nothing sensible to do with an error while quitting
+	_ = session.SaveWorkspace(m.tree.RootPaths(), m.sessionState())
+}
+
+// applies a saved state to a freshly loaded tree, returns the cmd to reopen the note
+func (m *model) restoreSession(state *session.State) tea.Cmd {
+	if state == nil {
+		return nil
+	}
+
+	if mode, ok := fstree.ParseSortMode(state.SortMode); ok {
+		m.tree.SetSortMode(mode)
+	}
+	expanded := make([]string, 0, len(state.ExpandedPaths))
+	for _, p := range state.ExpandedPaths {
+		expanded = append(expanded, m.tree.AbsPath(p))
+	}
+	m.tree.RestoreExpanded(expanded)
+	pins := make([]string, 0, len(state.Pins))
+	for _, p := range state.Pins {
+		pins = append(pins, m.tree.AbsPath(p))
+	}
+	m.tree.SetPins(pins)
+	m.history = history.Restore(m.absEntries(state.Recent), m.absEntries(state.Back), m.absEntries(state.Forward))
+	if state.SelectedPath != "" {
+		m.tree.RestoreSelection(m.tree.AbsPath(state.SelectedPath))
+	}
+
+	m.showSidebar = state.ShowSidebar
+	m.showStatusBar = state.ShowStatusBar
+	if state.SidebarWidth > 0 {
+		m.fsTreeWidth = state.SidebarWidth
+	}
+
+	if state.OpenNote == "" {
+		return nil
+	}
+	path := m.tree.AbsPath(state.OpenNote)
+	section := state.SectionIndex
+	return func() tea.Msg {
+		return note.LoadNoteMsg{Path: path, Section: section}
+	}
+}
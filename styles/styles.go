@@ -16,6 +16,10 @@ var (
 	HoverHighlight = lipgloss.Color("#91B4D5")
 	FolderBlue     = lipgloss.Color("#5FAFFF") // Blue for folder names in search
 	FileGreen      = lipgloss.Color("#98C379") // Green for file icons
+	AttachmentGray = lipgloss.Color("#8A8F98") // non note files
+	GitModified    = lipgloss.Color("#E5C07B")
+	GitUntracked   = lipgloss.Color("#98C379")
+	GitStaged      = lipgloss.Color("#61AFEF")
 )
 
 // ==================== icons (nerd fonts) ====================
@@ -23,7 +27,37 @@ var (
 	VerticalLine   = "│"
 	ArrowDownIcon  = "⌄"
 	ArrowRightIcon = "›"
+	MarkIcon       = "●" // multi selection marker in the tree
+	PinIcon        = "\uf08d"
+	SymlinkIcon    = "↪"
 	// need nerd fonts to render correctly, how I got them? https://fontawesome.com/v4/icon/folder has a unicode
 	FolderIcon = "\uf07b"
-	FileIcon   = "\uf0f6"
+	FileIcon   = "\uf0f6" // notes
+	ImageIcon  = "\uf1c5"
+	PdfIcon    = "\uf1c1"
+	OtherIcon  = "\uf016"
 )
+
+// Kind is a kind of file as named by FileKind.String, styles stays free of
+// anything but lipgloss
+type Kind string
+
+const (
+	KindNote  Kind = "note"
+	KindImage Kind = "image"
+	KindPDF   Kind = "pdf"
+	KindOther Kind = "file"
+)
+
+// KindIcon is the icon for a kind of file
+func KindIcon(kind Kind) string {
+	switch kind {
+	case KindNote:
+		return FileIcon
+	case KindImage:
+		return ImageIcon
+	case KindPDF:
+		return PdfIcon
+	}
+	return OtherIcon
+}
@@ -1,5 +1,7 @@
 package utils
 
+import "strings"
+
 // Removes the first occurence from the slice. Worst case O(n)
 func RemoveFromSlice[T comparable](slice []T, item T) []T {
 	for i, v := range slice {
@@ -9,3 +11,46 @@ func RemoveFromSlice[T comparable](slice []T, item T) []T {
 	}
 	return slice
 }
+
+// NaturalCompare compares strings the way people expect file names to sort,
+// runs of digits compare by value so "note 2" < "note 10". Letters compare
+// case insensitively with the raw string as a tie breaker to keep it stable.
+func NaturalCompare(a, b string) int {
+	la, lb := strings.ToLower(a), strings.ToLower(b)
+	i, j := 0, 0
+	for i < len(la) && j < len(lb) {
+		ca, cb := la[i], lb[j]
+		if isDigit(ca) && isDigit(cb) {
+			// compare the whole digit run, ignoring leading zeros
+			si, sj := i, j
+			for i < len(la) && isDigit(la[i]) {
+				i++
+			}
+			for j < len(lb) && isDigit(lb[j]) {
+				j++
+			}
+			na := strings.TrimLeft(la[si:i], "0")
+			nb := strings.TrimLeft(lb[sj:j], "0")
+			if len(na) != len(nb) {
+				return len(na) - len(nb)
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			continue
+		}
+		if ca != cb {
+			return int(ca) - int(cb)
+		}
+		i++
+		j++
+	}
+	if c := (len(la) - i) - (len(lb) - j); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+// tests natural ordering of names with numbers
+func TestNaturalCompare(t *testing.T) {
+	names := []string{"note 10", "Note 2", "note 1", "apple", "note 02b", "b", "note"}
+	slices.SortFunc(names, NaturalCompare)
+	want := []string{"apple", "b", "note", "note 1", "Note 2", "note 02b", "note 10"}
+	if !slices.Equal(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+
+	if NaturalCompare("a", "a") != 0 {
+		t.Error("equal strings should compare equal")
+	}
+	if NaturalCompare("A", "a") == 0 {
+		t.Error("case only differences should still be ordered for stability")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"mend/internal/ui/note"
+	"mend/internal/ui/palette"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const versionsPaletteID = "versions"
+
+// lists the old versions of the open note, picking one shows it in the note view
+func (m *model) openVersions(msg note.ShowVersionsMsg) tea.Cmd {
+	if msg.Path != m.noteView.Path {
+		return nil
+	}
+	m.versionList = msg.Versions
+	items := make([]palette.Item, 0, len(msg.Versions))
+	for _, version := range msg.Versions {
+		items = append(items, palette.Item{
+			Title:  version.Time.Local().Format("2006-01-02 15:04:05"),
+			Detail: fmt.Sprintf("%s, %d bytes", age(version.Time), version.Size),
+			Value:  version.Path,
+		})
+	}
+	title := "Versions of " + m.tree.RelPath(msg.Path)
+	if len(items) == 0 {
+		title += ": none yet, they are kept when a note is saved"
+	}
+	_, sizeCmd := m.palette.Update(tea.WindowSizeMsg{Width: m.terminalWidth, Height: m.terminalHeight})
+	return tea.Batch(sizeCmd, m.palette.Open(versionsPaletteID, title, items))
+}
+
+func (m *model) previewVersion(path string) tea.Cmd {
+	for _, version := range m.versionList {
+		if version.Path != path {
+			continue
+		}
+		if err := m.noteView.PreviewVersion(version); err != nil {
+			m.tree.ErrMsg = err.Error()
+		}
+		break
+	}
+	m.versionList = nil
+	return nil
+}
+
+// how long ago, roughly
+func age(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%d min ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hours ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%d days ago", int(d.Hours()/24))
+}